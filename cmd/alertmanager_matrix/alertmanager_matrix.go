@@ -2,52 +2,28 @@
 package main
 
 import (
-	"encoding/json"
+	"cmp"
 	"flag"
 	"log"
 	"log/slog"
-	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // embed time zones for scheduled silences
 
-	"github.com/gorilla/mux"
 	"gitlab.com/slxh/go/env"
 	"gopkg.in/yaml.v3"
 	mid "maunium.net/go/mautrix/id"
 
 	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
 	bot2 "gitlab.com/slxh/matrix/alertmanager_matrix/pkg/bot"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/config"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/webhook"
 )
 
-func requestHandler(client *bot2.Client, alertLabels bool, w http.ResponseWriter, r *http.Request) {
-	// Get room from request
-	room := client.Matrix.NewRoom(mid.RoomID(mux.Vars(r)["room"]))
-	if room.ID == "" || room.ID[0] != '!' {
-		log.Printf("Invalid room ID: %q", room.ID)
-		w.WriteHeader(http.StatusBadRequest)
-
-		return
-	}
-
-	// Parse the message
-	data := new(alertmanager.Message)
-	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
-		log.Printf("Error parsing message: %s", err)
-		w.WriteHeader(http.StatusBadRequest)
-
-		return
-	}
-
-	// Create readable messages for Matrix
-	plain, html := client.Formatter.FormatAlerts(data.Alerts, alertLabels)
-	log.Printf("Sending message to %s: %s", room.ID, plain)
-
-	if _, err := room.SendHTML(r.Context(), plain, html); err != nil {
-		log.Printf("Error sending message: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-	}
-}
-
 func loadFile(fileName string) string {
 	contents, err := os.ReadFile(fileName) //nolint:gosec // contents inclusion is the point
 	if err != nil {
@@ -77,11 +53,9 @@ func mapFromYAMLFile(fileName string) map[string]string {
 	return m
 }
 
-func formatter(colorFile, iconFile, htmlTemplateFile, textTemplateFile string) *bot2.Formatter {
-	var (
-		colors, icons              map[string]string
-		htmlTemplate, textTemplate string
-	)
+func formatter(cfg *config.Config, colorFile, iconFile string) *bot2.Formatter {
+	colors, icons := cfg.Colors, cfg.Icons
+	textTemplate, htmlTemplate := cfg.Templates.Text, cfg.Templates.HTML
 
 	if colorFile != "" {
 		colors = mapFromYAMLFile(colorFile)
@@ -91,17 +65,134 @@ func formatter(colorFile, iconFile, htmlTemplateFile, textTemplateFile string) *
 		icons = mapFromYAMLFile(iconFile)
 	}
 
-	if htmlTemplateFile != "" {
-		htmlTemplate = loadFile(htmlTemplateFile)
+	if cfg.Templates.HTMLFile != "" {
+		htmlTemplate = loadFile(cfg.Templates.HTMLFile)
 	}
 
-	if textTemplateFile != "" {
-		textTemplate = loadFile(textTemplateFile)
+	if cfg.Templates.TextFile != "" {
+		textTemplate = loadFile(cfg.Templates.TextFile)
 	}
 
 	return bot2.NewFormatter(textTemplate, htmlTemplate, colors, icons)
 }
 
+// configFileName returns the name of the configuration file from
+// the command line arguments or the `CONFIG` environment variable.
+// This is done before parsing other flags, as the configuration file provides their defaults.
+func configFileName(args []string) (fileName string) {
+	fileName = os.Getenv("CONFIG")
+
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		switch {
+		case !strings.HasPrefix(arg, "-") || name != "config":
+			continue
+		case hasValue:
+			fileName = value
+		case i+1 < len(args):
+			fileName = args[i+1]
+		}
+	}
+
+	return fileName
+}
+
+func loadConfig(fileName string) *config.Config {
+	if fileName == "" {
+		return config.Default()
+	}
+
+	cfg, err := config.Load(fileName)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %s", err) //nolint:revive // only called in main()
+	}
+
+	return cfg
+}
+
+func router(cfg *config.Config) *webhook.Router {
+	r := &webhook.Router{
+		Routes:   make([]*webhook.Route, len(cfg.Webhook.Routes)),
+		Fallback: cfg.Webhook.FallbackRooms,
+	}
+
+	for i, route := range cfg.Webhook.Routes {
+		// Matchers are checked during validation
+		matchers, _ := route.ParseMatchers()
+
+		r.Routes[i] = &webhook.Route{
+			Matchers:     matchers,
+			Rooms:        route.Rooms,
+			Continue:     route.Continue,
+			Alertmanager: route.Alertmanager,
+			Credentials:  credentials(route.Auth),
+		}
+	}
+
+	return r
+}
+
+func credentials(list []config.Credentials) []webhook.Credentials {
+	creds := make([]webhook.Credentials, len(list))
+
+	for i, c := range list {
+		creds[i].BearerToken = c.BearerToken
+
+		if c.BasicAuth != nil {
+			creds[i].Username = c.BasicAuth.Username
+			creds[i].Password = c.BasicAuth.Password
+		}
+	}
+
+	return creds
+}
+
+func auth(cfg *config.Config) *webhook.Auth {
+	a := &webhook.Auth{
+		Credentials: credentials(cfg.Webhook.Auth),
+		Rooms:       make(map[string][]webhook.Credentials, len(cfg.Webhook.RoomAuth)),
+	}
+
+	for room, list := range cfg.Webhook.RoomAuth {
+		a.Rooms[room] = credentials(list)
+	}
+
+	return a
+}
+
+func serverConfig(cfg *config.Config) *webhook.ServerConfig {
+	c := &webhook.ServerConfig{
+		Address:       cfg.Webhook.Address,
+		WebConfigFile: cfg.Webhook.WebConfigFile,
+	}
+
+	if cfg.Webhook.TLSEnabled() {
+		c.TLS = &cfg.Webhook.TLS
+
+		// Verify client certificates when a CA is given
+		if (c.TLS.ClientCAs != "" || c.TLS.ClientCAsText != "") && c.TLS.ClientAuth == "" {
+			c.TLS.ClientAuth = "RequireAndVerifyClientCert"
+		}
+	}
+
+	return c
+}
+
+// reloadOnHangup reloads the TLS configuration of the server on SIGHUP.
+func reloadOnHangup(server *webhook.Server) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	for range c {
+		if err := server.Reload(); err != nil {
+			log.Printf("Error reloading TLS configuration: %s", err)
+		} else {
+			log.Print("Reloaded TLS configuration")
+		}
+	}
+}
+
 func parseLogLevel(s string) (l slog.Level, err error) {
 	err = l.UnmarshalText([]byte(s))
 
@@ -121,42 +212,261 @@ func configureLogger(level string) error {
 	return nil
 }
 
+func reactions(cfg *config.Config) map[string]bot2.Reaction {
+	m := make(map[string]bot2.Reaction, len(cfg.Reactions))
+
+	for key, r := range cfg.Reactions {
+		m[key] = bot2.Reaction{Action: bot2.ReactionAction(r.Action), Duration: r.Duration}
+	}
+
+	return m
+}
+
+// location returns the location for a validated time zone, or nil if it is empty.
+func location(tz string) *time.Location {
+	if tz == "" {
+		return nil
+	}
+
+	loc, _ := time.LoadLocation(tz)
+
+	return loc
+}
+
+func userLocations(timezones map[string]string) map[mid.UserID]*time.Location {
+	m := make(map[mid.UserID]*time.Location, len(timezones))
+
+	for user, tz := range timezones {
+		m[mid.UserID(user)] = location(tz)
+	}
+
+	return m
+}
+
+func roomLocations(timezones map[string]string) map[string]*time.Location {
+	m := make(map[string]*time.Location, len(timezones))
+
+	for room, tz := range timezones {
+		m[room] = location(tz)
+	}
+
+	return m
+}
+
+func alertmanagers(cfg *config.Config) map[string]*alertmanager.Client {
+	clients := make(map[string]*alertmanager.Client, len(cfg.Alertmanagers))
+
+	for name, am := range cfg.Alertmanagers {
+		httpClient, err := am.HTTPClient()
+		if err != nil {
+			log.Fatalf("Error configuring Alertmanager client %q: %s", name, err) //nolint:revive // only called in main()
+		}
+
+		client, err := alertmanager.NewClientWithHTTPClient(httpClient, am.NodeURLs()...)
+		if err != nil {
+			log.Fatalf("Error creating Alertmanager client %q: %s", name, err) //nolint:revive // only called in main()
+		}
+
+		client.ExpectedPeers = am.ClusterPeers
+		clients[name] = client
+	}
+
+	return clients
+}
+
+func permission(p config.Permission) bot2.Permission {
+	users := make([]mid.UserID, len(p.Users))
+	for i, user := range p.Users {
+		users[i] = mid.UserID(user)
+	}
+
+	return bot2.Permission{PowerLevel: p.PowerLevel, Users: users, Servers: p.Servers}
+}
+
+func auditLogger(cfg *config.Config) *slog.Logger {
+	switch {
+	case cfg.Audit.File != "":
+		flags := os.O_WRONLY | os.O_APPEND | os.O_CREATE
+
+		file, err := os.OpenFile(cfg.Audit.File, flags, 0o600) //nolint:gosec // path from configuration
+		if err != nil {
+			log.Fatalf("Error opening audit log: %s", err) //nolint:revive // only called in main()
+		}
+
+		return slog.New(slog.NewJSONHandler(file, nil))
+	case cfg.Audit.Log:
+		return slog.Default()
+	default:
+		return nil
+	}
+}
+
+func login(cfg *config.Config) *bot2.LoginConfig {
+	if !cfg.Login.Configured() {
+		return nil
+	}
+
+	return &bot2.LoginConfig{Password: cfg.Login.Password, SharedSecretAuth: cfg.Login.SharedSecretAuth}
+}
+
+func encryption(cfg *config.Config) *bot2.CryptoConfig {
+	if cfg.Encryption.Store == "" {
+		return nil
+	}
+
+	return &bot2.CryptoConfig{
+		StorePath:   cfg.Encryption.Store,
+		PickleKey:   cfg.Encryption.PickleKey,
+		DeviceID:    mid.DeviceID(cfg.Encryption.DeviceID),
+		RecoveryKey: cfg.Encryption.RecoveryKey,
+	}
+}
+
+func roomFilters(filters map[string]config.AlertFilter) map[string]*alertmanager.AlertFilter {
+	m := make(map[string]*alertmanager.AlertFilter, len(filters))
+
+	for room, f := range filters {
+		// Matchers are checked during validation
+		matchers, _ := f.ParseMatchers()
+
+		m[room] = &alertmanager.AlertFilter{Matchers: matchers, Receiver: f.Receiver}
+	}
+
+	return m
+}
+
+func maintenance(cfg *config.Config) []*bot2.MaintenanceWindow {
+	windows := make([]*bot2.MaintenanceWindow, len(cfg.Maintenance.Windows))
+
+	for i, w := range cfg.Maintenance.Windows {
+		// Schedules and matchers are checked during validation
+		schedule, _ := w.ParseSchedule(cfg.Silences.Timezone)
+		matchers, _ := w.ParseMatchers()
+
+		windows[i] = &bot2.MaintenanceWindow{
+			Name:     w.Name,
+			Schedule: schedule,
+			Location: location(cmp.Or(w.Timezone, cfg.Silences.Timezone)),
+			Duration: w.Duration,
+			Matchers: matchers,
+			Comment:  w.Comment,
+			Rooms:    w.Rooms,
+
+			Alertmanager: w.Alertmanager,
+		}
+	}
+
+	return windows
+}
+
 func main() {
-	var addr, iconFile, colorFile, htmlTemplateFile, textTemplateFile, logLevel string
-
-	config := bot2.ClientConfig{}
-	alertLabels := false
-
-	flag.StringVar(&addr, "addr", ":4051", "Address to listen on.")
-	flag.StringVar(&config.Homeserver, "homeserver", "http://localhost:8008", "Homeserver to connect to.")
-	flag.StringVar(&config.UserID, "user-id", "", "User ID to connect with.")
-	flag.StringVar(&config.Token, "token", "", "Token to connect with.")
-	flag.StringVar(&config.Rooms, "rooms", "", "Comma separated list of allowed rooms. All rooms are allowed by default.")
-	flag.StringVar(&config.AlertManagerURL, "alertmanager", "http://localhost:9093", "Alertmanager to connect to.")
-	flag.StringVar(&config.MessageType, "message-type", "m.notice", "Type of message the bot uses.")
+	var iconFile, colorFile string
+
+	configFile := configFileName(os.Args[1:])
+	cfg := loadConfig(configFile)
+
+	flag.String("config", configFile, "YAML configuration file. Flags and environment variables override its values.")
+	flag.StringVar(&cfg.Webhook.Address, "addr", cfg.Webhook.Address, "Address to listen on.")
+	flag.StringVar(&cfg.Webhook.TLS.TLSCertPath, "tls-cert", cfg.Webhook.TLS.TLSCertPath, "TLS certificate file. Enables TLS.")
+	flag.StringVar(&cfg.Webhook.TLS.TLSKeyPath, "tls-key", cfg.Webhook.TLS.TLSKeyPath, "TLS key file.")
+	flag.StringVar(&cfg.Webhook.TLS.ClientCAs, "tls-client-ca", cfg.Webhook.TLS.ClientCAs, "CA file for verifying client certificates.")
+	flag.StringVar(&cfg.Webhook.WebConfigFile, "web-config-file", cfg.Webhook.WebConfigFile,
+		"Prometheus exporter toolkit web configuration file for TLS and basic authentication.")
+	flag.StringVar(&cfg.Homeserver, "homeserver", cfg.Homeserver, "Homeserver to connect to.")
+	flag.StringVar(&cfg.UserID, "user-id", cfg.UserID, "User ID to connect with.")
+	flag.StringVar(&cfg.Token, "token", cfg.Token, "Token to connect with.")
+	flag.StringVar(&cfg.Login.Password, "password", cfg.Login.Password, "Password to log in with if no token is set.")
+	flag.StringVar(&cfg.Login.SharedSecretAuth, "shared-secret-auth", cfg.Login.SharedSecretAuth,
+		"Secret of the devture shared secret authenticator module to log in with if no token is set.")
+	flag.StringVar(&cfg.Encryption.Store, "encryption-store", cfg.Encryption.Store,
+		"Database file to store encryption keys in. Enables end-to-end encryption.")
+	flag.StringVar(&cfg.Encryption.PickleKey, "pickle-key", cfg.Encryption.PickleKey,
+		"Key to encrypt the stored encryption keys with.")
+	flag.StringVar(&cfg.Encryption.DeviceID, "device-id", cfg.Encryption.DeviceID, "Device ID of the token.")
+	flag.StringVar(&cfg.Encryption.RecoveryKey, "recovery-key", cfg.Encryption.RecoveryKey,
+		"Recovery key to verify the device with.")
+	flag.Var(&cfg.Rooms, "rooms", "Comma separated list of allowed rooms. All rooms are allowed by default.")
+	flag.StringVar(&cfg.Alertmanager.URL, "alertmanager", cfg.Alertmanager.URL,
+		"Alertmanager to connect to. Defaults to "+config.DefaultAlertmanagerURL+".")
+	flag.Var(&cfg.Alertmanager.URLs, "alertmanager-urls",
+		"Comma separated list of Alertmanager nodes in a highly available cluster. Cannot be combined with -alertmanager.")
+	flag.StringVar(&cfg.MessageType, "message-type", cfg.MessageType, "Type of message the bot uses.")
 	flag.StringVar(&iconFile, "icon-file", "", "YAML file with icons for message types.")
 	flag.StringVar(&colorFile, "color-file", "", "YAML file with colors for message types.")
-	flag.StringVar(&htmlTemplateFile, "html-template", "", "HTML template for alert messages.")
-	flag.StringVar(&textTemplateFile, "text-template", "", "Plain-text template for alert messages.")
-	flag.StringVar(&logLevel, "log-level", "info", "Log level")
-	flag.BoolVar(&alertLabels, "show-labels", false, "show labels of alerts messages.")
+	flag.StringVar(&cfg.Templates.HTMLFile, "html-template", cfg.Templates.HTMLFile, "HTML template for alert messages.")
+	flag.StringVar(&cfg.Templates.TextFile, "text-template", cfg.Templates.TextFile, "Plain-text template for alert messages.")
+	flag.StringVar(&cfg.Store.Path, "store", cfg.Store.Path, "Database file to persist the bot state in. State is kept in memory by default.")
+	flag.StringVar(&cfg.Audit.File, "audit-file", cfg.Audit.File, "File to append the audit log to as JSON lines.")
+	flag.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level")
+	flag.BoolVar(&cfg.Webhook.ShowLabels, "show-labels", cfg.Webhook.ShowLabels, "show labels of alerts messages.")
+	flag.BoolVar(&cfg.Webhook.EditMessages, "edit-messages", cfg.Webhook.EditMessages,
+		"Edit the previous message of an alert group instead of sending a new message.")
+	flag.BoolVar(&cfg.Webhook.Threads, "threads", cfg.Webhook.Threads, "Send notifications for an alert group in a thread.")
 
 	if err := env.ParseWithFlags(); err != nil {
 		log.Fatalf("Error parsing flags and environment variables: %s", err)
 	}
 
-	if err := configureLogger(logLevel); err != nil {
-		log.Fatalf("Error configuring logger: %s", err)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err)
 	}
 
-	if config.UserID == "" || config.Token == "" {
-		log.Fatal("Error: user ID or token not supplied")
+	if err := configureLogger(cfg.LogLevel); err != nil {
+		log.Fatalf("Error configuring logger: %s", err)
 	}
 
 	log.Printf("Connecting to Matrix homeserver at %s as %s, and to Alertmanager at %s",
-		config.Homeserver, config.UserID, config.AlertManagerURL)
+		cfg.Homeserver, cfg.UserID, strings.Join(cfg.Alertmanager.NodeURLs(), ", "))
+
+	st, err := store.New(cfg.Store.Path)
+	if err != nil {
+		log.Fatalf("Error opening store: %s", err)
+	}
 
-	client, err := bot2.NewClient(&config, formatter(colorFile, iconFile, htmlTemplateFile, textTemplateFile))
+	httpClient, err := cfg.Alertmanager.HTTPClient()
+	if err != nil {
+		log.Fatalf("Error configuring Alertmanager client: %s", err)
+	}
+
+	clientConfig := &bot2.ClientConfig{
+		Homeserver:       cfg.Homeserver,
+		UserID:           cfg.UserID,
+		Token:            cfg.Token,
+		MessageType:      cfg.MessageType,
+		Rooms:            cfg.Rooms,
+		AlertManagerURLs: cfg.Alertmanager.NodeURLs(),
+		AliasTTL:         cfg.AliasTTL,
+		EditMessages:     cfg.Webhook.EditMessages,
+		EditMaxAge:       cfg.Webhook.EditMaxAge,
+		Threads:          cfg.Webhook.Threads,
+		Store:            st,
+		Reactions:        reactions(cfg),
+		ConfirmThreshold: cfg.Silences.ConfirmThreshold,
+		Location:         location(cfg.Silences.Timezone),
+		UserLocations:    userLocations(cfg.Silences.UserTimezones),
+		RoomLocations:    roomLocations(cfg.Silences.RoomTimezones),
+		RoomFilters:      roomFilters(cfg.RoomFilters),
+		ClusterPeers:     cfg.Alertmanager.ClusterPeers,
+
+		AlertManagerHTTPClient: httpClient,
+
+		Maintenance:          maintenance(cfg),
+		MaintenanceLookahead: cfg.Maintenance.Lookahead,
+
+		Alertmanagers:     alertmanagers(cfg),
+		RoomAlertmanagers: cfg.RoomAlertmanagers,
+
+		ReadPermission:  permission(cfg.Permissions.Read),
+		WritePermission: permission(cfg.Permissions.Write),
+
+		AuditLogger: auditLogger(cfg),
+		AuditRoom:   cfg.Audit.Room,
+
+		Login:  login(cfg),
+		Crypto: encryption(cfg),
+	}
+
+	client, err := bot2.NewClient(clientConfig, formatter(cfg, colorFile, iconFile))
 	if err != nil {
 		log.Fatalf("Error connecting to Matrix: %s", err)
 	}
@@ -166,17 +476,19 @@ func main() {
 		log.Fatal(client.Run())
 	}()
 
-	// Create the HTTP handler
-	handler := func(w http.ResponseWriter, r *http.Request) {
-		requestHandler(client, alertLabels, w, r)
-	}
-
 	// Create/start HTTP server
-	r := mux.NewRouter()
-	server := &http.Server{Addr: addr, Handler: r, ReadTimeout: time.Second}
+	handler := webhook.NewHandler(client, &webhook.HandlerConfig{
+		Router:     router(cfg),
+		Auth:       auth(cfg),
+		ShowLabels: cfg.Webhook.ShowLabels,
+	})
+
+	server, err := webhook.NewServer(handler, serverConfig(cfg))
+	if err != nil {
+		log.Fatalf("Error configuring webhook server: %s", err)
+	}
 
-	r.HandleFunc("/{room}", handler).Methods("POST")
+	go reloadOnHangup(server)
 
-	log.Print("Listening on ", addr)
 	log.Fatal(server.ListenAndServe())
 }
@@ -5,14 +5,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"time"
 
+	httptransport "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
 	alertmanager "github.com/prometheus/alertmanager/api/v2/client"
 	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	"github.com/prometheus/alertmanager/api/v2/client/alertgroup"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	"github.com/prometheus/alertmanager/api/v2/client/receiver"
 	"github.com/prometheus/alertmanager/api/v2/client/silence"
 	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/alertmanager/template"
 
 	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
@@ -21,49 +27,160 @@ import (
 // ErrNoAlert is returned when no matching alert can be found.
 var ErrNoAlert = errors.New("no alert")
 
+var errNoURLs = errors.New("at least one Alertmanager URL is required")
+
 // Client represents a multi-functional Alertmanager API client.
+// Requests are sent to one of the nodes of a (highly available) Alertmanager cluster,
+// and retried on the next node when a node is unavailable.
+// Changes such as new silences are sent to a single node, which shares them with the cluster.
+// These are only retried on the next node if the connection to the node failed.
 type Client struct {
+	// API is the API client of the first node.
 	API *alertmanager.AlertmanagerAPI
+
+	// ExpectedPeers is the expected number of peers in the cluster (optional).
+	// The cluster is degraded when it has fewer peers.
+	ExpectedPeers int
+
+	nodes []*node
 }
 
-// NewClient creates an Alertmanager API client.
-func NewClient(baseURL string) (*Client, error) {
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid Alertmanager URL: %w", err)
-	}
+// NewClient creates an Alertmanager API client for the nodes with the given base URLs.
+// The nodes are tried in the given order.
+func NewClient(baseURLs ...string) (*Client, error) {
+	return NewClientWithHTTPClient(nil, baseURLs...)
+}
 
-	if u.Path == "" {
-		u.Path = alertmanager.DefaultBasePath
+// NewClientWithHTTPClient creates an Alertmanager API client for the nodes with the given base URLs,
+// using the given HTTP client for requests. The default HTTP client is used if it is nil.
+// The nodes are tried in the given order.
+func NewClientWithHTTPClient(httpClient *http.Client, baseURLs ...string) (*Client, error) {
+	if len(baseURLs) == 0 {
+		return nil, errNoURLs
 	}
 
-	client := &Client{
-		API: alertmanager.NewHTTPClientWithConfig(nil, &alertmanager.TransportConfig{
-			Host:     u.Host,
-			BasePath: u.Path,
-			Schemes:  []string{u.Scheme},
-		}),
+	client := &Client{nodes: make([]*node, len(baseURLs))}
+
+	for i, baseURL := range baseURLs {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Alertmanager URL: %w", err)
+		}
+
+		if u.Path == "" {
+			u.Path = alertmanager.DefaultBasePath
+		}
+
+		transport := httptransport.NewWithClient(u.Host, u.Path, []string{u.Scheme}, httpClient)
+
+		client.nodes[i] = &node{
+			url:     baseURL,
+			api:     alertmanager.New(transport, strfmt.Default),
+			healthy: true,
+		}
 	}
 
+	client.API = client.nodes[0].api
+
 	return client, nil
 }
 
+// AlertFilter contains the filter for retrieving alerts.
+type AlertFilter struct {
+	// Silenced includes silenced alerts.
+	Silenced bool
+
+	// Inhibited includes inhibited alerts.
+	Inhibited bool
+
+	// Matchers contains the label matchers that alerts must match.
+	Matchers labels.Matchers
+
+	// Receiver is a regular expression matching the receivers of alerts (optional).
+	Receiver string
+}
+
 // GetAlerts retrieves all silenced or non-silenced alerts.
 func (am *Client) GetAlerts(ctx context.Context, silenced bool) ([]*Alert, error) {
-	alertResp, err := am.API.Alert.GetAlerts(&alert.GetAlertsParams{
+	return am.FilterAlerts(ctx, &AlertFilter{Silenced: silenced})
+}
+
+// FilterAlerts retrieves the alerts matching the filter.
+func (am *Client) FilterAlerts(ctx context.Context, filter *AlertFilter) ([]*Alert, error) {
+	params := &alert.GetAlertsParams{
 		Active:      util.PtrTo(true),
-		Inhibited:   util.PtrTo(false),
-		Silenced:    &silenced,
+		Inhibited:   &filter.Inhibited,
+		Silenced:    &filter.Silenced,
 		Unprocessed: util.PtrTo(true),
+		Filter:      filterMatchers(filter.Matchers),
 		Context:     ctx,
+	}
+
+	if filter.Receiver != "" {
+		params.Receiver = &filter.Receiver
+	}
+
+	alertResp, err := call(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*alert.GetAlertsOK, error) {
+		return api.Alert.GetAlerts(params)
+	})
+	if err != nil {
+		return nil, wrapError("error retrieving commands from alertmanager", err)
+	}
+
+	return newAlerts(alertResp.GetPayload()), nil
+}
+
+// GetAlertGroups retrieves the alert groups containing alerts matching the filter.
+func (am *Client) GetAlertGroups(ctx context.Context, filter *AlertFilter) ([]*AlertGroup, error) {
+	params := &alertgroup.GetAlertGroupsParams{
+		Active:    util.PtrTo(true),
+		Inhibited: &filter.Inhibited,
+		Silenced:  &filter.Silenced,
+		Filter:    filterMatchers(filter.Matchers),
+		Context:   ctx,
+	}
+
+	if filter.Receiver != "" {
+		params.Receiver = &filter.Receiver
+	}
+
+	resp, err := call(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*alertgroup.GetAlertGroupsOK, error) {
+		return api.Alertgroup.GetAlertGroups(params)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving commands from alertmanager: %w", err)
+		return nil, wrapError("error retrieving alert groups", err)
+	}
+
+	groups := make([]*AlertGroup, 0, len(resp.GetPayload()))
+
+	for _, g := range resp.GetPayload() {
+		if len(g.Alerts) == 0 {
+			continue
+		}
+
+		group := &AlertGroup{Labels: g.Labels, Alerts: newAlerts(g.Alerts)}
+		if g.Receiver != nil {
+			group.Receiver = util.ValueOrDefault(g.Receiver.Name)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// filterMatchers returns matchers in the format of the filter parameter of the Alertmanager API.
+func filterMatchers(matchers labels.Matchers) []string {
+	filter := make([]string, len(matchers))
+	for i, m := range matchers {
+		filter[i] = m.String()
 	}
 
-	alerts := alertResp.GetPayload()
+	return filter
+}
 
-	// Map alerts to compatible type
+// newAlerts maps alerts from the Alertmanager API to compatible alerts.
+func newAlerts(alerts models.GettableAlerts) []*Alert {
 	as := make([]*Alert, len(alerts))
 	for i, a := range alerts {
 		as[i] = &Alert{
@@ -76,15 +193,18 @@ func (am *Client) GetAlerts(ctx context.Context, silenced bool) ([]*Alert, error
 				GeneratorURL: string(a.GeneratorURL),
 				Fingerprint:  util.ValueOrDefault(a.Fingerprint),
 			},
+			SilencedBy:  a.Status.SilencedBy,
+			InhibitedBy: a.Status.InhibitedBy,
 		}
 	}
 
-	return as, nil
+	return as
 }
 
 // GetAlert retrieves an alert with a given ID.
+// Silenced and inhibited alerts are included.
 func (am *Client) GetAlert(ctx context.Context, id string) (*Alert, error) {
-	alerts, err := am.GetAlerts(ctx, true)
+	alerts, err := am.FilterAlerts(ctx, &AlertFilter{Silenced: true, Inhibited: true})
 	if err != nil {
 		return nil, err
 	}
@@ -100,9 +220,11 @@ func (am *Client) GetAlert(ctx context.Context, id string) (*Alert, error) {
 
 // GetSilences returns a list of silences from Alertmanager.
 func (am *Client) GetSilences(ctx context.Context) ([]Silence, error) {
-	silencesResp, err := am.API.Silence.GetSilences(&silence.GetSilencesParams{Context: ctx})
+	silencesResp, err := call(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*silence.GetSilencesOK, error) {
+		return api.Silence.GetSilences(&silence.GetSilencesParams{Context: ctx})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving silences: %w", err)
+		return nil, wrapError("error retrieving silences", err)
 	}
 
 	silences := make([]Silence, len(silencesResp.GetPayload()))
@@ -114,14 +236,33 @@ func (am *Client) GetSilences(ctx context.Context) ([]Silence, error) {
 	return silences, nil
 }
 
+// GetSilence returns the silence with the given ID.
+func (am *Client) GetSilence(ctx context.Context, id string) (*Silence, error) {
+	resp, err := call(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*silence.GetSilenceOK, error) {
+		return api.Silence.GetSilence(&silence.GetSilenceParams{
+			SilenceID: strfmt.UUID(id),
+			Context:   ctx,
+		})
+	})
+	if err != nil {
+		return nil, wrapError("error retrieving silence", err)
+	}
+
+	return &Silence{GettableSilence: resp.GetPayload()}, nil
+}
+
 // CreateSilence creates the given silence.
+// If the silence has an ID, the existing silence is updated instead.
+// Alertmanager may replace the existing silence by a new one, which is returned.
 func (am *Client) CreateSilence(ctx context.Context, s Silence) (string, error) {
-	resp, err := am.API.Silence.PostSilences(&silence.PostSilencesParams{
-		Silence: &models.PostableSilence{Silence: s.GettableSilence.Silence},
-		Context: ctx,
+	resp, err := callWrite(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*silence.PostSilencesOK, error) {
+		return api.Silence.PostSilences(&silence.PostSilencesParams{
+			Silence: &models.PostableSilence{ID: s.ID(), Silence: s.GettableSilence.Silence},
+			Context: ctx,
+		})
 	})
 	if err != nil {
-		return "", fmt.Errorf("error creating silence: %w", err)
+		return "", wrapError("error creating silence", err)
 	}
 
 	return resp.GetPayload().SilenceID, nil
@@ -129,13 +270,44 @@ func (am *Client) CreateSilence(ctx context.Context, s Silence) (string, error)
 
 // DeleteSilence deletes the silence with the given ID.
 func (am *Client) DeleteSilence(ctx context.Context, id string) error {
-	_, err := am.API.Silence.DeleteSilence(&silence.DeleteSilenceParams{
-		SilenceID: strfmt.UUID(id),
-		Context:   ctx,
+	_, err := callWrite(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*silence.DeleteSilenceOK, error) {
+		return api.Silence.DeleteSilence(&silence.DeleteSilenceParams{
+			SilenceID: strfmt.UUID(id),
+			Context:   ctx,
+		})
 	})
 	if err != nil {
-		return fmt.Errorf("error deleting silence: %w", err)
+		return wrapError("error deleting silence", err)
 	}
 
 	return nil
 }
+
+// GetStatus returns the status of Alertmanager.
+func (am *Client) GetStatus(ctx context.Context) (*Status, error) {
+	resp, err := call(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*general.GetStatusOK, error) {
+		return api.General.GetStatus(&general.GetStatusParams{Context: ctx})
+	})
+	if err != nil {
+		return nil, wrapError("error retrieving status", err)
+	}
+
+	return &Status{AlertmanagerStatus: resp.GetPayload()}, nil
+}
+
+// GetReceivers returns the names of the configured receivers.
+func (am *Client) GetReceivers(ctx context.Context) ([]string, error) {
+	resp, err := call(ctx, am, func(api *alertmanager.AlertmanagerAPI) (*receiver.GetReceiversOK, error) {
+		return api.Receiver.GetReceivers(&receiver.GetReceiversParams{Context: ctx})
+	})
+	if err != nil {
+		return nil, wrapError("error retrieving receivers", err)
+	}
+
+	names := make([]string, len(resp.GetPayload()))
+	for i, r := range resp.GetPayload() {
+		names[i] = util.ValueOrDefault(r.Name)
+	}
+
+	return names, nil
+}
@@ -2,9 +2,11 @@ package alertmanager
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/alertmanager/template"
 )
 
@@ -15,6 +17,7 @@ const (
 	resolvedStatus     = "resolved"
 	suppressedStatus   = "suppressed"
 	silencedStatus     = "silenced"
+	inhibitedStatus    = "inhibited"
 	severityLabel      = "severity"
 	alertNameLabel     = "alertname"
 )
@@ -25,10 +28,50 @@ type Message struct {
 	Alerts []*Alert `json:"alerts"`
 }
 
+// GroupKey returns the key identifying the alert group of the message,
+// or an empty string if it is not set.
+func (m *Message) GroupKey() string {
+	if m.Message == nil {
+		return ""
+	}
+
+	return m.Message.GroupKey
+}
+
+// GroupLabels returns the labels identifying the alert group of the message,
+// or nil if they are not set.
+func (m *Message) GroupLabels() map[string]string {
+	if m.Message == nil || m.Data == nil {
+		return nil
+	}
+
+	return m.Data.GroupLabels
+}
+
+// AlertGroup represents a group of alerts as grouped by Alertmanager.
+type AlertGroup struct {
+	// Receiver is the name of the receiver of the group.
+	Receiver string
+
+	// Labels contains the labels identifying the group.
+	Labels map[string]string
+
+	// Alerts contains the alerts in the group.
+	Alerts []*Alert
+}
+
 // Alert represents an Alert received from Alertmanager via webhook.
 // It is extended with the `status` attribute, and various convenient functions for formatting.
 type Alert struct {
 	*template.Alert
+
+	// SilencedBy contains the IDs of the silences silencing the alert.
+	// This is only set for alerts retrieved from the Alertmanager API.
+	SilencedBy []string `json:"silencedBy,omitempty"`
+
+	// InhibitedBy contains the fingerprints of the alerts inhibiting the alert.
+	// This is only set for alerts retrieved from the Alertmanager API.
+	InhibitedBy []string `json:"inhibitedBy,omitempty"`
 }
 
 // AlertName returns the value of the `alertname` label.
@@ -40,13 +83,22 @@ func (a *Alert) AlertName() string {
 	return ""
 }
 
+// Resolved returns true if the alert is resolved.
+func (a *Alert) Resolved() bool {
+	return a.Status == resolvedStatus
+}
+
 // StatusString returns a string representing the status.
-// This is either `resolved`, `silenced`, the value of the `severity` label, or `alert`.
+// This is either `resolved`, `silenced`, `inhibited`, the value of the `severity` label, or `alert`.
 func (a *Alert) StatusString() string {
 	if a.Status == resolvedStatus {
 		return resolvedStatus
 	}
 
+	if a.Inhibited() && !a.Silenced() {
+		return inhibitedStatus
+	}
+
 	if a.Status == suppressedStatus || a.Status == silencedStatus {
 		return silencedStatus
 	}
@@ -58,6 +110,16 @@ func (a *Alert) StatusString() string {
 	return alertStatus
 }
 
+// Silenced returns true if the alert is silenced by one or more silences.
+func (a *Alert) Silenced() bool {
+	return len(a.SilencedBy) > 0
+}
+
+// Inhibited returns true if the alert is inhibited by one or more alerts.
+func (a *Alert) Inhibited() bool {
+	return len(a.InhibitedBy) > 0
+}
+
 // Summary returns the `summary` annotation when set,
 // the `resolved` annotation for `resolved` messages,
 // or an empty string if neither annotation is present.
@@ -83,3 +145,29 @@ func (a *Alert) LabelString() string {
 
 	return "{" + strings.Join(labels, ",") + "}"
 }
+
+// Matches returns true if the labels of the alert match all given matchers.
+func (a *Alert) Matches(matchers labels.Matchers) bool {
+	for _, m := range matchers {
+		if !m.Matches(a.Labels[m.Name]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LabelMatchers returns matchers matching the given labels exactly, sorted by label name.
+func LabelMatchers(lbls map[string]string) labels.Matchers {
+	matchers := make(labels.Matchers, 0, len(lbls))
+
+	for name, value := range lbls {
+		matchers = append(matchers, &labels.Matcher{Type: labels.MatchEqual, Name: name, Value: value})
+	}
+
+	slices.SortFunc(matchers, func(a, b *labels.Matcher) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return matchers
+}
@@ -0,0 +1,278 @@
+package alertmanager
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	alertmanager "github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+)
+
+// nodeRetryInterval is the duration after which an unavailable node is tried again.
+const nodeRetryInterval = 30 * time.Second
+
+// NodeStatus contains the health of an Alertmanager node.
+type NodeStatus struct {
+	// URL is the base URL of the node.
+	URL string
+
+	// Healthy is true if the last request to the node succeeded, or if no request was sent yet.
+	Healthy bool
+
+	// Error contains the error of the last failed request, if the node is unhealthy.
+	Error error
+
+	// CheckedAt is the time of the last request to the node.
+	CheckedAt time.Time
+}
+
+// node represents a node in an Alertmanager cluster.
+type node struct {
+	url string
+	api *alertmanager.AlertmanagerAPI
+
+	mu        sync.Mutex
+	healthy   bool
+	err       error
+	checkedAt time.Time
+}
+
+// setError updates the health of the node with the result of a request.
+// Changes of the health are logged.
+func (n *node) setError(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch {
+	case err != nil && n.healthy:
+		log.Printf("Alertmanager node %s is unavailable: %s", n.url, err)
+	case err == nil && !n.healthy:
+		log.Printf("Alertmanager node %s is available again", n.url)
+	}
+
+	n.healthy, n.err, n.checkedAt = err == nil, err, time.Now()
+}
+
+// status returns the health of the node.
+func (n *node) status() NodeStatus {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return NodeStatus{URL: n.url, Healthy: n.healthy, Error: n.err, CheckedAt: n.checkedAt}
+}
+
+// available returns true if requests should be sent to the node:
+// if it is healthy, or if it was unhealthy for longer than the retry interval.
+func (n *node) available(now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.healthy || now.Sub(n.checkedAt) >= nodeRetryInterval
+}
+
+// Nodes returns the health of the nodes, in the configured order.
+func (am *Client) Nodes() []NodeStatus {
+	nodes := make([]NodeStatus, len(am.nodes))
+	for i, n := range am.nodes {
+		nodes[i] = n.status()
+	}
+
+	return nodes
+}
+
+// CheckHealth checks the health of all nodes by requesting their status, and returns their health
+// and the status of Alertmanager reported by the first node that returned it.
+// An error is returned if no node returned its status.
+func (am *Client) CheckHealth(ctx context.Context) ([]NodeStatus, *Status, error) {
+	var (
+		status *Status
+		err    error
+	)
+
+	for _, n := range am.nodes {
+		resp, nodeErr := n.api.General.GetStatus(&general.GetStatusParams{Context: ctx})
+		if ctx.Err() != nil {
+			err = cmp.Or(err, ctx.Err())
+
+			break
+		}
+
+		if nodeErr != nil && unavailable(nodeErr) {
+			n.setError(nodeErr)
+		} else {
+			n.setError(nil)
+
+			nodeErr = cmp.Or(authError(n, nodeErr), nodeErr)
+		}
+
+		switch {
+		case status != nil:
+		case nodeErr != nil:
+			err = nodeErr
+		default:
+			status, err = &Status{AlertmanagerStatus: resp.GetPayload()}, nil
+		}
+	}
+
+	if status == nil {
+		return am.Nodes(), nil, wrapError("error retrieving status", err)
+	}
+
+	return am.Nodes(), status, nil
+}
+
+// orderedNodes returns the nodes in the order they should be tried:
+// available nodes first, in the configured order.
+// Unhealthy nodes become available again after the retry interval,
+// so that a recovered node is used again instead of staying behind the other nodes.
+func (am *Client) orderedNodes() []*node {
+	nodes := slices.Clone(am.nodes)
+	now := time.Now()
+
+	slices.SortStableFunc(nodes, func(a, b *node) int {
+		switch ah, bh := a.available(now), b.available(now); {
+		case ah == bh:
+			return 0
+		case ah:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	return nodes
+}
+
+// call calls the given function with the API client of each node until it succeeds.
+// Only errors indicating that the node is unavailable cause the next node to be tried.
+// The error of the last node is returned if all nodes are unavailable.
+func call[T any](ctx context.Context, am *Client, fn func(api *alertmanager.AlertmanagerAPI) (T, error)) (T, error) {
+	return callNodes(ctx, am, true, fn)
+}
+
+// callWrite is like [call], but for requests that are not idempotent, such as creating a silence.
+// The next node is only tried if the request could not be sent, as the node may have processed it otherwise.
+func callWrite[T any](ctx context.Context, am *Client,
+	fn func(api *alertmanager.AlertmanagerAPI) (T, error),
+) (T, error) {
+	return callNodes(ctx, am, false, fn)
+}
+
+// callNodes calls the given function with the API client of each node until it succeeds.
+// Requests that are not idempotent are only retried if they were not sent.
+func callNodes[T any](ctx context.Context, am *Client, idempotent bool,
+	fn func(api *alertmanager.AlertmanagerAPI) (T, error),
+) (T, error) {
+	var (
+		resp T
+		err  error
+	)
+
+	for _, n := range am.orderedNodes() {
+		resp, err = fn(n.api)
+
+		switch {
+		case ctx.Err() != nil:
+			return resp, err
+		case err != nil && unavailable(err):
+			n.setError(err)
+
+			if !idempotent && !notSent(err) {
+				return resp, err
+			}
+		default:
+			n.setError(nil)
+
+			if authErr := authError(n, err); authErr != nil {
+				return resp, authErr
+			}
+
+			return resp, err
+		}
+	}
+
+	return resp, err
+}
+
+// AuthError is returned when Alertmanager rejects a request because of missing or invalid credentials.
+type AuthError struct {
+	// URL is the base URL of the node that rejected the request.
+	URL string
+
+	// StatusCode is the HTTP status code of the response: 401 or 403.
+	StatusCode int
+}
+
+// Error implements the error interface.
+func (e *AuthError) Error() string {
+	if e.StatusCode == http.StatusForbidden {
+		return fmt.Sprintf("Alertmanager at %s denied access to the bot (%d %s), check its permissions",
+			e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("Alertmanager at %s did not accept the credentials of the bot (%d %s), check its configuration",
+		e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// authError returns an [AuthError] if the error is caused by an authentication or authorization failure,
+// or nil otherwise.
+func authError(n *node, err error) error {
+	var codeErr interface{ IsCode(code int) bool }
+	if !errors.As(err, &codeErr) {
+		return nil
+	}
+
+	for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		if codeErr.IsCode(code) {
+			return &AuthError{URL: n.url, StatusCode: code}
+		}
+	}
+
+	return nil
+}
+
+// wrapError wraps an error of an API request with a message.
+// Authentication errors are returned as is, so that they are reported clearly.
+func wrapError(msg string, err error) error {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// unavailable returns true if an error indicates that an Alertmanager node is unavailable.
+// This is the case for connection errors and server errors.
+func unavailable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+
+	var serverErr interface{ IsServerError() bool }
+
+	return errors.As(err, &serverErr) && serverErr.IsServerError()
+}
+
+// notSent returns true if an error indicates that a request was not sent,
+// because no connection to the node could be made.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &dnsErr)
+}
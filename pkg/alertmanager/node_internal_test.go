@@ -0,0 +1,379 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	alertmanager "github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/receiver"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+// backend returns the URL of a test Alertmanager node that responds with the given status code.
+// Healthy nodes return a single receiver with the given name.
+// A code of 0 returns the URL of a node that is down.
+func backend(t *testing.T, code int, name string) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+
+		if code == http.StatusOK {
+			fmt.Fprintf(w, `[{"name":%q}]`, name)
+		}
+	}))
+
+	if code == 0 {
+		srv.Close()
+	} else {
+		t.Cleanup(srv.Close)
+	}
+
+	return srv.URL
+}
+
+func getReceiver(ctx context.Context) func(api *alertmanager.AlertmanagerAPI) (string, error) {
+	return func(api *alertmanager.AlertmanagerAPI) (string, error) {
+		resp, err := api.Receiver.GetReceivers(&receiver.GetReceiversParams{Context: ctx})
+		if err != nil {
+			return "", err
+		}
+
+		return *resp.GetPayload()[0].Name, nil
+	}
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		codes    []int
+		receiver string
+		authCode int
+		healthy  []bool
+	}{
+		{
+			name:     "healthy",
+			codes:    []int{http.StatusOK, http.StatusOK},
+			receiver: "node0",
+			healthy:  []bool{true, true},
+		},
+		{
+			name:     "first down",
+			codes:    []int{0, http.StatusOK},
+			receiver: "node1",
+			healthy:  []bool{false, true},
+		},
+		{
+			name:     "server error",
+			codes:    []int{http.StatusBadGateway, http.StatusOK},
+			receiver: "node1",
+			healthy:  []bool{false, true},
+		},
+		{
+			name:     "unauthorized",
+			codes:    []int{http.StatusUnauthorized, http.StatusOK},
+			authCode: http.StatusUnauthorized,
+			healthy:  []bool{true, true},
+		},
+		{
+			name:     "forbidden",
+			codes:    []int{http.StatusForbidden, http.StatusOK},
+			authCode: http.StatusForbidden,
+			healthy:  []bool{true, true},
+		},
+		{
+			name:     "forbidden after down",
+			codes:    []int{0, http.StatusForbidden},
+			authCode: http.StatusForbidden,
+			healthy:  []bool{false, true},
+		},
+		{
+			name:    "all down",
+			codes:   []int{0, http.StatusServiceUnavailable},
+			healthy: []bool{false, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			urls := make([]string, len(test.codes))
+			for i, code := range test.codes {
+				urls[i] = backend(t, code, fmt.Sprintf("node%d", i))
+			}
+
+			am, err := NewClient(urls...)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			name, err := call(t.Context(), am, getReceiver(t.Context()))
+
+			var authErr *AuthError
+
+			switch {
+			case test.authCode != 0:
+				if !errors.As(err, &authErr) || authErr.StatusCode != test.authCode {
+					t.Errorf("call() error = %v, want auth error %d", err, test.authCode)
+				}
+			case test.receiver == "":
+				if err == nil || errors.As(err, &authErr) {
+					t.Errorf("call() error = %v, want unavailable error", err)
+				}
+			case err != nil || name != test.receiver:
+				t.Errorf("call() = %q, %v, want %q", name, err, test.receiver)
+			}
+
+			for i, n := range am.Nodes() {
+				if n.Healthy != test.healthy[i] {
+					t.Errorf("node %d healthy = %v, want %v", i, n.Healthy, test.healthy[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCallRetriesRecoveredNode(t *testing.T) {
+	t.Parallel()
+
+	am, err := NewClient(backend(t, http.StatusOK, "node0"), backend(t, http.StatusOK, "node1"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	am.nodes[0].setError(errors.New("down"))
+
+	if name, _ := call(t.Context(), am, getReceiver(t.Context())); name != "node1" {
+		t.Errorf("call() = %q within retry interval, want node1", name)
+	}
+
+	am.nodes[0].mu.Lock()
+	am.nodes[0].checkedAt = time.Now().Add(-nodeRetryInterval)
+	am.nodes[0].mu.Unlock()
+
+	if name, _ := call(t.Context(), am, getReceiver(t.Context())); name != "node0" {
+		t.Errorf("call() = %q after retry interval, want node0", name)
+	}
+
+	if !am.nodes[0].status().Healthy {
+		t.Error("recovered node is not healthy")
+	}
+}
+
+// Behaviours of test Alertmanager nodes for writes.
+const (
+	writeOK = iota
+	writeDown
+	writeTimeout
+	writeServerError
+)
+
+// writeBackend returns the URL of a test Alertmanager node that handles the creation of silences,
+// and the number of requests it received.
+func writeBackend(t *testing.T, behaviour int) (string, *atomic.Int32) {
+	t.Helper()
+
+	requests := new(atomic.Int32)
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		switch behaviour {
+		case writeTimeout:
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		case writeServerError:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"silenceID":"silence"}`)
+		}
+	}))
+
+	if behaviour == writeDown {
+		srv.Close()
+	} else {
+		t.Cleanup(srv.Close)
+		t.Cleanup(func() { close(done) }) // runs before closing the server
+	}
+
+	return srv.URL, requests
+}
+
+func TestCallWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		first    int
+		id       string
+		requests [2]int32
+	}{
+		{name: "healthy", first: writeOK, id: "silence", requests: [2]int32{1, 0}},
+		{name: "connection refused", first: writeDown, id: "silence", requests: [2]int32{0, 1}},
+		{name: "timeout", first: writeTimeout, requests: [2]int32{1, 0}},
+		{name: "server error", first: writeServerError, requests: [2]int32{1, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			url0, requests0 := writeBackend(t, test.first)
+			url1, requests1 := writeBackend(t, writeOK)
+
+			am, err := NewClientWithHTTPClient(&http.Client{Timeout: 100 * time.Millisecond}, url0, url1)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			silence := Silence{GettableSilence: &models.GettableSilence{Silence: models.Silence{}}}
+
+			id, err := am.CreateSilence(t.Context(), silence)
+			if id != test.id || (err == nil) != (test.id != "") {
+				t.Errorf("CreateSilence() = %q, %v, want %q", id, err, test.id)
+			}
+
+			if got := [2]int32{requests0.Load(), requests1.Load()}; got != test.requests {
+				t.Errorf("CreateSilence() sent %v requests, want %v", got, test.requests)
+			}
+		})
+	}
+}
+
+func TestOrderedNodes(t *testing.T) {
+	t.Parallel()
+
+	const recent, expired = time.Second, nodeRetryInterval + time.Second
+
+	type state struct {
+		healthy bool
+		age     time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		states []state
+		want   []int
+	}{
+		{
+			name:   "all healthy",
+			states: []state{{true, 0}, {true, 0}, {true, 0}},
+			want:   []int{0, 1, 2},
+		},
+		{
+			name:   "first unhealthy",
+			states: []state{{false, recent}, {true, 0}, {true, 0}},
+			want:   []int{1, 2, 0},
+		},
+		{
+			name:   "first unhealthy after retry interval",
+			states: []state{{false, expired}, {true, 0}, {true, 0}},
+			want:   []int{0, 1, 2},
+		},
+		{
+			name:   "mixed",
+			states: []state{{false, recent}, {false, expired}, {true, 0}},
+			want:   []int{1, 2, 0},
+		},
+		{
+			name:   "all unhealthy",
+			states: []state{{false, recent}, {false, recent}, {false, recent}},
+			want:   []int{0, 1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			am := &Client{}
+			now := time.Now()
+
+			for i, s := range test.states {
+				am.nodes = append(am.nodes, &node{
+					url:       fmt.Sprint(i),
+					healthy:   s.healthy,
+					checkedAt: now.Add(-s.age),
+				})
+			}
+
+			got := make([]int, 0, len(am.nodes))
+			for _, n := range am.orderedNodes() {
+				got = append(got, slices.Index(am.nodes, n))
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("orderedNodes() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAuthError(t *testing.T) {
+	t.Parallel()
+
+	n := &node{url: "http://alertmanager:9093"}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil},
+		{name: "other error", err: errors.New("error")},
+		{name: "not found", err: runtime.NewAPIError("getReceivers", nil, http.StatusNotFound)},
+		{name: "server error", err: runtime.NewAPIError("getReceivers", nil, http.StatusInternalServerError)},
+		{
+			name: "unauthorized",
+			err:  runtime.NewAPIError("getReceivers", nil, http.StatusUnauthorized),
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "forbidden",
+			err:  runtime.NewAPIError("getReceivers", nil, http.StatusForbidden),
+			want: http.StatusForbidden,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("error: %w", runtime.NewAPIError("getReceivers", nil, http.StatusForbidden)),
+			want: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := authError(n, test.err)
+
+			if test.want == 0 {
+				if err != nil {
+					t.Errorf("authError() = %v, want nil", err)
+				}
+
+				return
+			}
+
+			var authErr *AuthError
+			if !errors.As(err, &authErr) || authErr.StatusCode != test.want || authErr.URL != n.url {
+				t.Errorf("authError() = %v, want status %d for %s", err, test.want, n.url)
+			}
+		})
+	}
+}
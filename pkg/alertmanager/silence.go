@@ -46,7 +46,7 @@ func (s *Silence) SetMatchers(matchers labels.Matchers) {
 
 // StartsAt returns the time that the Silence starts at.
 func (s *Silence) StartsAt() time.Time {
-	return time.Time(util.ValueOrDefault(s.GettableSilence.EndsAt))
+	return time.Time(util.ValueOrDefault(s.GettableSilence.StartsAt))
 }
 
 // EndsAt returns the time that the Silence ends at.
@@ -0,0 +1,72 @@
+package alertmanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/prometheus/alertmanager/api/v2/models"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
+)
+
+// Cluster states reported by Alertmanager.
+const (
+	ClusterReady    = "ready"
+	ClusterSettling = "settling"
+	ClusterDisabled = "disabled"
+)
+
+// configHashLength is the length of the configuration hash.
+const configHashLength = 12
+
+// Status represents the status of Alertmanager.
+type Status struct {
+	*models.AlertmanagerStatus
+}
+
+// Version returns the version of Alertmanager.
+func (s *Status) Version() string {
+	return util.ValueOrDefault(util.ValueOrDefault(s.VersionInfo).Version)
+}
+
+// Revision returns the revision Alertmanager was built from.
+func (s *Status) Revision() string {
+	return util.ValueOrDefault(util.ValueOrDefault(s.VersionInfo).Revision)
+}
+
+// StartedAt returns the time Alertmanager was started.
+func (s *Status) StartedAt() time.Time {
+	return time.Time(util.ValueOrDefault(s.Uptime))
+}
+
+// ClusterStatus returns the state of the cluster.
+// This is either `ready`, `settling` or `disabled`.
+func (s *Status) ClusterStatus() string {
+	return util.ValueOrDefault(util.ValueOrDefault(s.Cluster).Status)
+}
+
+// Peers returns the peers in the cluster.
+func (s *Status) Peers() []*models.PeerStatus {
+	return util.ValueOrDefault(s.Cluster).Peers
+}
+
+// ConfigHash returns a short hash of the loaded configuration.
+func (s *Status) ConfigHash() string {
+	sum := sha256.Sum256([]byte(util.ValueOrDefault(util.ValueOrDefault(s.Config).Original)))
+
+	return hex.EncodeToString(sum[:])[:configHashLength]
+}
+
+// Degraded returns true if the cluster is not ready, or has fewer peers than expected.
+// The number of peers is not checked if expectedPeers is zero.
+func (s *Status) Degraded(expectedPeers int) bool {
+	switch s.ClusterStatus() {
+	case ClusterDisabled:
+		return false
+	case ClusterReady:
+		return len(s.Peers()) < expectedPeers
+	default:
+		return true
+	}
+}
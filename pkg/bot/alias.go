@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"maunium.net/go/mautrix"
+	mid "maunium.net/go/mautrix/id"
+)
+
+// DefaultAliasTTL is the default duration that resolved room aliases are cached for.
+const DefaultAliasTTL = 15 * time.Minute
+
+// Room resolution errors.
+var (
+	ErrInvalidRoom  = errors.New("invalid room ID or alias")
+	ErrUnknownAlias = errors.New("unknown room alias")
+)
+
+// aliasCache contains a cache of resolved room aliases.
+type aliasCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[mid.RoomAlias]aliasCacheEntry
+}
+
+type aliasCacheEntry struct {
+	roomID  mid.RoomID
+	expires time.Time
+}
+
+func newAliasCache(ttl time.Duration) *aliasCache {
+	return &aliasCache{
+		ttl:     ttl,
+		entries: make(map[mid.RoomAlias]aliasCacheEntry),
+	}
+}
+
+// get returns the cached room ID for an alias, if present and not expired.
+func (c *aliasCache) get(alias mid.RoomAlias) (mid.RoomID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[alias]
+	if !ok {
+		return "", false
+	}
+
+	if time.Now().After(e.expires) {
+		delete(c.entries, alias)
+
+		return "", false
+	}
+
+	return e.roomID, true
+}
+
+// set caches the room ID for an alias.
+func (c *aliasCache) set(alias mid.RoomAlias, roomID mid.RoomID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[alias] = aliasCacheEntry{roomID: roomID, expires: time.Now().Add(c.ttl)}
+}
+
+// ResolveRoom returns the room ID for a room ID (`!id:server`) or alias (`#alias:server`).
+// Aliases are resolved using the room directory of the homeserver, and are cached.
+// An error wrapping [ErrUnknownAlias] is returned if the alias does not exist,
+// and [ErrInvalidRoom] is returned if the given value is not a room ID or alias.
+func (c *Client) ResolveRoom(ctx context.Context, room string) (mid.RoomID, error) {
+	switch {
+	case strings.HasPrefix(room, "!"):
+		return mid.RoomID(room), nil
+	case !strings.HasPrefix(room, "#"):
+		return "", fmt.Errorf("%w: %q", ErrInvalidRoom, room)
+	}
+
+	alias := mid.RoomAlias(room)
+
+	if roomID, ok := c.aliases.get(alias); ok {
+		return roomID, nil
+	}
+
+	resp, err := c.Matrix.Client.ResolveAlias(ctx, alias)
+	if errors.Is(err, mautrix.MNotFound) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownAlias, room)
+	} else if err != nil {
+		return "", fmt.Errorf("cannot resolve room alias %q: %w", room, err)
+	}
+
+	c.aliases.set(alias, resp.RoomID)
+
+	return resp.RoomID, nil
+}
@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// Number of audit entries shown by the `audit` command.
+const (
+	defaultAuditCount = 10
+	maxAuditCount     = 100
+)
+
+// auditSourceKey is the context key of the source of an action for the audit trail.
+type auditSourceKey struct{}
+
+// auditSource contains the room and command that requested an action.
+type auditSource struct {
+	roomID  mid.RoomID
+	command string
+}
+
+// withAuditSource returns a context recording the room and command that requested an action.
+func withAuditSource(ctx context.Context, roomID mid.RoomID, command string) context.Context {
+	return context.WithValue(ctx, auditSourceKey{}, auditSource{roomID: roomID, command: command})
+}
+
+// auditSourceFrom returns the source of an action recorded in the context, if any.
+func auditSourceFrom(ctx context.Context) auditSource {
+	src, _ := ctx.Value(auditSourceKey{}).(auditSource)
+
+	return src
+}
+
+// audit adds an entry to the audit trail.
+// The room, command and Alertmanager backend are taken from the context if not set.
+// The entry is also logged and posted in the audit room, if configured.
+// Errors are logged, as they should not prevent the action.
+func (c *Client) audit(ctx context.Context, entry *store.AuditEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	src := auditSourceFrom(ctx)
+	if entry.RoomID == "" {
+		entry.RoomID = src.roomID
+	}
+
+	if entry.Command == "" {
+		entry.Command = src.command
+	}
+
+	if entry.Alertmanager == "" {
+		entry.Alertmanager = alertmanagerName(ctx)
+	}
+
+	if err := c.store.AddAuditEntry(ctx, entry); err != nil {
+		log.Printf("Error adding audit entry: %s", err)
+	}
+
+	if c.auditLogger != nil {
+		logAuditEntry(ctx, c.auditLogger, entry)
+	}
+
+	if c.auditRoom != "" {
+		c.postAuditEntry(ctx, entry)
+	}
+}
+
+// auditError returns the message of an error for an audit entry, or an empty string if the error is nil.
+func auditError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// logAuditEntry logs an audit entry as a structured log message.
+// Entries of failed actions are logged as warnings.
+func logAuditEntry(ctx context.Context, logger *slog.Logger, entry *store.AuditEntry) {
+	level := slog.LevelInfo
+	if entry.Error != "" {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{slog.String("action", entry.Action)}
+
+	for _, attr := range []struct{ key, value string }{
+		{"user_id", entry.UserID.String()},
+		{"room_id", entry.RoomID.String()},
+		{"command", entry.Command},
+		{"alertmanager", entry.Alertmanager},
+		{"silence_id", entry.SilenceID},
+		{"matchers", entry.Matchers},
+		{"details", entry.Details},
+		{"error", entry.Error},
+	} {
+		if attr.value != "" {
+			attrs = append(attrs, slog.String(attr.key, attr.value))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, "audit", attrs...)
+}
+
+// postAuditEntry posts an audit entry in the audit room.
+func (c *Client) postAuditEntry(ctx context.Context, entry *store.AuditEntry) {
+	roomID, err := c.ResolveRoom(ctx, c.auditRoom)
+	if err != nil {
+		log.Printf("Error posting audit entry: %s", err)
+
+		return
+	}
+
+	if _, err := c.Matrix.NewRoom(roomID).SendMarkdown(ctx, formatAuditEntry(entry)); err != nil {
+		log.Printf("Error posting audit entry in %s: %s", roomID, err)
+	}
+}
+
+// formatAuditEntry returns a Markdown formatted audit entry.
+func formatAuditEntry(entry *store.AuditEntry) string {
+	var sb strings.Builder
+
+	icon := "📝"
+	if entry.Error != "" {
+		icon = "⚠️"
+	}
+
+	fmt.Fprintf(&sb, "%s %s **%s**", icon, entry.Time.Format(silenceTimeFormat), entry.Action)
+
+	if entry.UserID != "" {
+		fmt.Fprintf(&sb, " by %s", entry.UserID)
+	}
+
+	if entry.RoomID != "" {
+		fmt.Fprintf(&sb, " in %s", entry.RoomID)
+	}
+
+	if entry.Alertmanager != "" {
+		fmt.Fprintf(&sb, " on %s", makeCode(backendPrefix+entry.Alertmanager))
+	}
+
+	if entry.SilenceID != "" {
+		fmt.Fprintf(&sb, ": silence *%s*", entry.SilenceID)
+	}
+
+	if entry.Matchers != "" {
+		fmt.Fprintf(&sb, " matching %s", makeCode(entry.Matchers))
+	}
+
+	if entry.Details != "" {
+		fmt.Fprintf(&sb, " (%s)", entry.Details)
+	}
+
+	if entry.Command != "" {
+		fmt.Fprintf(&sb, "  \nCommand: %s", makeCode(entry.Command))
+	}
+
+	if entry.Error != "" {
+		fmt.Fprintf(&sb, "  \nError: %s", entry.Error)
+	}
+
+	return sb.String()
+}
+
+// auditScope returns the room that the audit entries shown for a request are limited to.
+// All entries are shown in the audit room, and to users with the write permission if it is restricted.
+func (c *Client) auditScope(req *request) mid.RoomID {
+	if c.auditRoom != "" {
+		if roomID, err := c.ResolveRoom(req.ctx, c.auditRoom); err == nil && roomID == req.roomID {
+			return ""
+		}
+	}
+
+	if c.writePermission.restricted() && c.permitted(req.ctx, &c.writePermission, req.roomID, req.sender) {
+		return ""
+	}
+
+	return req.roomID
+}
+
+// Audit returns a Markdown formatted list of the last n entries of the audit trail.
+// Only entries of actions requested in the given room are listed, unless the room is empty.
+func (c *Client) Audit(ctx context.Context, roomID mid.RoomID, n int) string {
+	entries, err := c.store.AuditEntries(ctx, roomID, n)
+	if err != nil {
+		return fmt.Sprintf("Error reading audit trail: %s", err)
+	}
+
+	if len(entries) == 0 {
+		return "No actions recorded"
+	}
+
+	formatted := make([]string, len(entries))
+	for i, entry := range entries {
+		formatted[i] = formatAuditEntry(entry)
+	}
+
+	return strings.Join(formatted, "\n\n")
+}
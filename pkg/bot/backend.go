@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// DefaultAlertmanager is the name of the default Alertmanager backend.
+const DefaultAlertmanager = "default"
+
+// backendPrefix is the prefix of the Alertmanager backend selector in commands, e.g. `@prod`.
+const backendPrefix = "@"
+
+var errUnknownAlertmanager = errors.New("unknown Alertmanager")
+
+// alertmanagerKey is the context key of the name of the selected Alertmanager backend.
+type alertmanagerKey struct{}
+
+// WithAlertmanager returns a context selecting the Alertmanager backend with the given name.
+// The default backend is used for unknown or empty names.
+func WithAlertmanager(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, alertmanagerKey{}, name)
+}
+
+// alertmanagerName returns the name of the Alertmanager backend selected by the context,
+// or an empty string if none is selected.
+func alertmanagerName(ctx context.Context) string {
+	name, _ := ctx.Value(alertmanagerKey{}).(string)
+
+	return name
+}
+
+// backend returns the client of the Alertmanager backend selected by the context.
+func (c *Client) backend(ctx context.Context) *alertmanager.Client {
+	if am, ok := c.alertmanagers[alertmanagerName(ctx)]; ok {
+		return am
+	}
+
+	return c.Alertmanager
+}
+
+// selectBackend returns a context selecting the Alertmanager backend for a notification in a room.
+// This is the backend the notification was received for, or the default backend of the room.
+// The notification is optional.
+func (c *Client) selectBackend(ctx context.Context, roomID mid.RoomID, n *store.Notification) context.Context {
+	if n != nil && n.Alertmanager != "" {
+		return WithAlertmanager(ctx, n.Alertmanager)
+	}
+
+	for room, name := range c.roomAlertmanagers {
+		if id, err := c.ResolveRoom(ctx, room); err == nil && id == roomID {
+			return WithAlertmanager(ctx, name)
+		}
+	}
+
+	return ctx
+}
+
+// selectCommandBackend selects the Alertmanager backend for a command request.
+// A backend selector (e.g. `@prod`) as the first argument takes precedence over the backend of
+// the notification and room of the request. The remaining arguments are returned.
+func (c *Client) selectCommandBackend(req *request, args []string) ([]string, error) {
+	if len(args) > 0 && strings.HasPrefix(args[0], backendPrefix) && !strings.Contains(args[0], ":") {
+		name := strings.TrimPrefix(args[0], backendPrefix)
+		if _, ok := c.alertmanagers[name]; !ok && name != DefaultAlertmanager {
+			return nil, fmt.Errorf("%w: %s", errUnknownAlertmanager, makeCode(name))
+		}
+
+		req.ctx = WithAlertmanager(req.ctx, name)
+
+		// Run the default command if only the backend is given, like for an empty command
+		if len(args) == 1 {
+			return []string{""}, nil
+		}
+
+		return args[1:], nil
+	}
+
+	req.ctx = c.selectBackend(req.ctx, req.roomID, req.notification)
+
+	return args, nil
+}
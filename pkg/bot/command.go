@@ -0,0 +1,224 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"gitlab.com/slxh/matrix/bot"
+	mevent "maunium.net/go/mautrix/event"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// CommandPrefixes contains the prefixes of bot commands.
+var CommandPrefixes = []string{"!alertmanager", "!alert"} //nolint:gochecknoglobals // used as constant
+
+// request contains the context in which a command was given.
+type request struct {
+	ctx    context.Context //nolint:containedctx // request scoped
+	event  *bot.Event
+	sender mid.UserID
+	roomID mid.RoomID
+
+	// thread contains the root of the thread the command was given in, if any.
+	thread mid.EventID
+
+	// replyTo contains the message the command is a reply to, if any.
+	replyTo mid.EventID
+
+	// notification contains the notification that the command refers to, if any.
+	// This is the notification replied to, or the notification at the root of the thread.
+	notification *store.Notification
+
+	// sent is called with the event ID of the response, if set.
+	sent func(eventID mid.EventID)
+}
+
+// newRequest creates a request for a command message.
+func (c *Client) newRequest(ctx context.Context, e *bot.Event, content *mevent.MessageEventContent) *request {
+	req := &request{
+		ctx:     ctx,
+		event:   e,
+		sender:  e.Sender,
+		roomID:  e.RoomID,
+		thread:  content.RelatesTo.GetThreadParent(),
+		replyTo: content.RelatesTo.GetNonFallbackReplyTo(),
+	}
+
+	if req.replyTo != "" {
+		req.notification, _ = c.store.NotificationByEvent(ctx, req.roomID, req.replyTo)
+	}
+
+	if req.notification == nil && req.thread != "" {
+		req.notification, _ = c.store.NotificationByEvent(ctx, req.roomID, req.thread)
+	}
+
+	return req
+}
+
+// matchers returns the matchers for the alerts that the request refers to,
+// or nil if the request does not refer to specific alerts.
+func (r *request) matchers() labels.Matchers {
+	if r.notification == nil {
+		return nil
+	}
+
+	return r.notification.Matchers()
+}
+
+// handleMessage handles messages containing bot commands.
+// Commands are handled here instead of by the Matrix bot,
+// so that the room and thread of the command are available to the command handlers.
+func (c *Client) handleMessage(ctx context.Context, e *bot.Event) {
+	if !c.Matrix.NewRoom(e.RoomID).Allowed() || e.Sender == c.Matrix.Client.UserID {
+		return
+	}
+
+	content, err := e.MessageEventContent()
+	if err != nil || content.RelatesTo.GetReplaceID() != "" {
+		return
+	}
+
+	if c.handleConfirmationReply(ctx, e, content) {
+		return
+	}
+
+	text, ok := c.commandText(ctx, content)
+	if !ok {
+		return
+	}
+
+	req := c.newRequest(withAuditSource(ctx, e.RoomID, strings.TrimSpace(text)), e, content)
+
+	if !c.permitted(ctx, &c.readPermission, e.RoomID, e.Sender) {
+		_, _ = c.reply(req, c.deny(e.Sender, e.RoomID, auditSourceFrom(req.ctx).command, "use this bot"))
+
+		return
+	}
+
+	args, err := c.selectCommandBackend(req, splitCommand(text))
+	if err != nil {
+		_, _ = c.reply(req, bot.NewMarkdownMessage(err.Error()))
+
+		return
+	}
+
+	if response := c.rootCommand(req).Execute(e.Sender, "", args...); response != nil {
+		eventID, err := c.reply(req, response)
+		if err != nil {
+			log.Printf("Error sending response: %s", err)
+
+			_, _ = c.reply(req, bot.NewTextMessage("Error: "+err.Error()))
+
+			return
+		}
+
+		if req.sent != nil {
+			req.sent(eventID)
+		}
+	}
+}
+
+// commandText returns the command in a message, without the prefix or highlight.
+func (c *Client) commandText(ctx context.Context, content *mevent.MessageEventContent) (string, bool) {
+	body := content.Body
+	if content.RelatesTo.GetReplyTo() != "" {
+		body = mevent.TrimReplyFallbackText(body)
+	}
+
+	prefixes := slices.Clone(CommandPrefixes)
+	prefixes = append(prefixes, c.Matrix.Client.UserID.String()+": ")
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(body, prefix) {
+			return strings.TrimPrefix(body, prefix), true
+		}
+	}
+
+	resp, err := c.Matrix.Client.GetOwnDisplayName(ctx)
+	if err == nil && resp.DisplayName != "" && strings.HasPrefix(body, resp.DisplayName+": ") {
+		return strings.TrimPrefix(body, resp.DisplayName+": "), true
+	}
+
+	return "", false
+}
+
+// rootCommand returns the root command containing all commands for a request.
+// Commands registered on the Matrix bot are included, but are overridden by the commands of this bot.
+func (c *Client) rootCommand(req *request) *bot.Command {
+	root := &bot.Command{
+		Subcommands:    maps.Clone(c.Matrix.Config.Commands),
+		MessageHandler: unknownCommandHandler,
+	}
+
+	maps.Copy(root.Subcommands, c.commands(req))
+	root.Subcommands["help"] = root.HelpCommand()
+
+	return root
+}
+
+// reply sends a response to a request.
+// The response is sent in the thread of the request, if any.
+func (c *Client) reply(req *request, msg *bot.Message) (mid.EventID, error) {
+	content := &mevent.MessageEventContent{
+		MsgType:       cmp.Or(msg.MsgType, c.Matrix.Config.MessageType),
+		Body:          msg.Body,
+		Format:        msg.Format,
+		FormattedBody: msg.FormattedBody,
+	}
+
+	if req.thread != "" {
+		content.RelatesTo = (&mevent.RelatesTo{}).SetThread(req.thread, req.event.ID)
+	}
+
+	resp, err := c.Matrix.Client.SendMessageEvent(req.ctx, req.roomID, mevent.EventMessage, content)
+	if err != nil {
+		return "", fmt.Errorf("error sending message: %w", err)
+	}
+
+	return resp.EventID, nil
+}
+
+// unknownCommandHandler returns a response for an unknown command.
+func unknownCommandHandler(_ mid.UserID, cmd string, args ...string) *bot.Message {
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	resp := "unknown command: " + makeCode(cmd)
+
+	if len(args) > 0 {
+		codes := make([]string, len(args))
+		for i, arg := range args {
+			codes[i] = makeCode(arg)
+		}
+
+		resp += fmt.Sprintf(" (args: %s)", strings.Join(codes, ", "))
+	}
+
+	return bot.NewMarkdownMessage(resp)
+}
+
+// makeCode formats a string as inline code.
+func makeCode(s string) string {
+	return "`" + strings.Trim(strconv.Quote(s), `"`) + "`"
+}
+
+// splitCommand splits a command into arguments.
+// Every line after the first is returned as a single argument prefixed with a newline.
+func splitCommand(text string) []string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines[1:] {
+		lines[i+1] = "\n" + line
+	}
+
+	return append(strings.Split(lines[0], " "), lines[1:]...)
+}
@@ -0,0 +1,22 @@
+package bot
+
+import (
+	mid "maunium.net/go/mautrix/id"
+)
+
+// CryptoConfig contains the configuration of end-to-end encryption.
+type CryptoConfig struct {
+	// StorePath is the path to the SQLite database the encryption keys and room state are stored in.
+	StorePath string
+
+	// PickleKey is the key the encryption keys are encrypted with in the store.
+	PickleKey string
+
+	// DeviceID is the ID of the device of the access token (optional).
+	// It is requested from the homeserver if not set.
+	DeviceID mid.DeviceID
+
+	// RecoveryKey is the recovery key of the cross-signing keys of the bot user (optional).
+	// The device of the bot is verified using the cross-signing keys if set.
+	RecoveryKey string
+}
@@ -0,0 +1,60 @@
+//go:build e2ee
+
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"maunium.net/go/mautrix/crypto/cryptohelper"
+	mevent "maunium.net/go/mautrix/event"
+)
+
+// initCrypto enables end-to-end encryption.
+// Events in encrypted rooms are decrypted before they are handled,
+// and messages sent to encrypted rooms are encrypted.
+func (c *Client) initCrypto(ctx context.Context, config *CryptoConfig) error {
+	if c.Matrix.Client.DeviceID == "" {
+		resp, err := c.Matrix.Client.Whoami(ctx)
+		if err != nil {
+			return fmt.Errorf("error requesting device ID: %w", err)
+		}
+
+		c.Matrix.Client.DeviceID = resp.DeviceID
+	}
+
+	helper, err := cryptohelper.NewCryptoHelper(c.Matrix.Client, []byte(config.PickleKey), config.StorePath)
+	if err != nil {
+		return fmt.Errorf("error creating crypto helper: %w", err)
+	}
+
+	helper.DecryptErrorCallback = func(e *mevent.Event, err error) {
+		log.Printf("Error decrypting event %s in %s: %s", e.ID, e.RoomID, err)
+	}
+
+	if err := helper.Init(ctx); err != nil {
+		return fmt.Errorf("error initializing encryption: %w", err)
+	}
+
+	if config.RecoveryKey != "" {
+		if err := helper.Machine().VerifyWithRecoveryKey(ctx, config.RecoveryKey); err != nil {
+			return fmt.Errorf("error verifying device with recovery key: %w", err)
+		}
+	}
+
+	c.Matrix.Client.Crypto = helper
+
+	log.Printf("End-to-end encryption enabled for device %s", c.Matrix.Client.DeviceID)
+
+	return nil
+}
+
+// closeCrypto closes the store of the encryption keys, if encryption is enabled.
+func (c *Client) closeCrypto() {
+	if helper, ok := c.Matrix.Client.Crypto.(*cryptohelper.CryptoHelper); ok {
+		if err := helper.Close(); err != nil {
+			log.Printf("Error closing crypto store: %s", err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !e2ee
+
+package bot
+
+import (
+	"context"
+	"errors"
+)
+
+var errCryptoUnsupported = errors.New(
+	"end-to-end encryption is not supported by this build, build with CGO_ENABLED=1 and -tags e2ee,goolm")
+
+// initCrypto returns an error, as end-to-end encryption is not supported by this build.
+func (c *Client) initCrypto(context.Context, *CryptoConfig) error {
+	return errCryptoUnsupported
+}
+
+// closeCrypto does nothing, as end-to-end encryption is not supported by this build.
+func (c *Client) closeCrypto() {}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gitlab.com/slxh/matrix/bot"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// unprocessedStatus is the state of alerts that have not been processed by Alertmanager yet.
+const unprocessedStatus = "unprocessed"
+
+// InhibitedAlerts returns the inhibited alerts matching the filter.
+// Alerts that are both inhibited and silenced are included.
+func (c *Client) InhibitedAlerts(ctx context.Context, filter *alertmanager.AlertFilter, showLabels bool) *bot.Message {
+	filter.Silenced = true
+	filter.Inhibited = true
+
+	alerts, err := c.backend(ctx).FilterAlerts(ctx, filter)
+	if err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	alerts = slices.DeleteFunc(alerts, func(a *alertmanager.Alert) bool {
+		return !a.Inhibited()
+	})
+
+	if len(alerts) == 0 {
+		return bot.NewTextMessage("No inhibited alerts")
+	}
+
+	return bot.NewHTMLMessage(c.Formatter.FormatAlerts(alerts, showLabels))
+}
+
+// Explain returns a Markdown formatted explanation of why an alert does not notify.
+// This lists the silences silencing the alert, and the alerts inhibiting it.
+func (c *Client) Explain(ctx context.Context, fingerprint string) string {
+	alerts, err := c.backend(ctx).FilterAlerts(ctx, &alertmanager.AlertFilter{Silenced: true, Inhibited: true})
+	if err != nil {
+		return fmt.Sprintf("Alertmanager error: %s", err)
+	}
+
+	i := slices.IndexFunc(alerts, func(a *alertmanager.Alert) bool { return a.Fingerprint == fingerprint })
+	if i < 0 {
+		return fmt.Sprintf("No active alert with fingerprint `%s`", fingerprint)
+	}
+
+	a := alerts[i]
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s **%s** (%s) ", c.Formatter.icon(a.StatusString()), a.AlertName(), a.Fingerprint)
+
+	switch {
+	case a.Status == unprocessedStatus:
+		sb.WriteString("has not been processed by Alertmanager yet.\n")
+	case !a.Silenced() && !a.Inhibited():
+		sb.WriteString("is neither silenced nor inhibited.\n")
+	default:
+		sb.WriteString("is suppressed.\n")
+	}
+
+	if a.Silenced() {
+		sb.WriteString("\nSilenced by:\n")
+		sb.WriteString(c.explainSilences(ctx, a.SilencedBy))
+	}
+
+	if a.Inhibited() {
+		sb.WriteString("\nInhibited by:\n\n")
+
+		for _, fp := range a.InhibitedBy {
+			j := slices.IndexFunc(alerts, func(a *alertmanager.Alert) bool { return a.Fingerprint == fp })
+			if j < 0 {
+				fmt.Fprintf(&sb, "- `%s`\n", fp)
+
+				continue
+			}
+
+			b := alerts[j]
+			fmt.Fprintf(&sb, "- %s **%s** %s: %s (%s)\n", c.Formatter.icon(b.StatusString()),
+				strings.ToUpper(b.StatusString()), b.AlertName(), b.Summary(), b.Fingerprint)
+		}
+	}
+
+	return sb.String()
+}
+
+// explainSilences returns the Markdown formatted silences with the given IDs.
+func (c *Client) explainSilences(ctx context.Context, ids []string) string {
+	silences := make([]alertmanager.Silence, 0, len(ids))
+
+	var errs strings.Builder
+
+	for _, id := range ids {
+		s, err := c.backend(ctx).GetSilence(ctx, id)
+		if err != nil {
+			fmt.Fprintf(&errs, "- `%s`: %s\n", id, err)
+
+			continue
+		}
+
+		silences = append(silences, *s)
+	}
+
+	return c.Formatter.FormatSilences(silences, "active") + errs.String()
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// receiverKeyword is the keyword preceding the receiver in a list command.
+const receiverKeyword = "receiver"
+
+var errMissingReceiver = errors.New("missing receiver after " + receiverKeyword)
+
+// parseAlertFilter returns the alert filter given in the arguments of a list command.
+// The arguments contain label matchers, and optionally `receiver <regex>`.
+// Nil is returned when the arguments are empty.
+func parseAlertFilter(args []string) (*alertmanager.AlertFilter, error) {
+	filter := &alertmanager.AlertFilter{}
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		if args[i] != receiverKeyword {
+			rest = append(rest, args[i])
+
+			continue
+		}
+
+		if i+1 == len(args) {
+			return nil, errMissingReceiver
+		}
+
+		i++
+		filter.Receiver = args[i]
+	}
+
+	if s := strings.TrimSpace(strings.Join(rest, " ")); s != "" {
+		matchers, err := labels.ParseMatchers(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matchers %q: %w", s, err)
+		}
+
+		filter.Matchers = matchers
+	}
+
+	if filter.Receiver == "" && len(filter.Matchers) == 0 {
+		return nil, nil //nolint:nilnil // no filter given
+	}
+
+	return filter, nil
+}
+
+// alertFilter returns the alert filter for a list command.
+// A filter given in the arguments takes precedence over the alerts that the request refers to,
+// which take precedence over the default filter of the room.
+func (c *Client) alertFilter(req *request, silenced bool, args []string) (*alertmanager.AlertFilter, error) {
+	filter, err := parseAlertFilter(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case filter != nil:
+	case req.notification != nil:
+		filter = &alertmanager.AlertFilter{Matchers: req.matchers()}
+	default:
+		filter = c.roomFilter(req.ctx, req.roomID)
+	}
+
+	filter.Silenced = silenced
+
+	return filter, nil
+}
+
+// roomFilter returns a copy of the default alert filter of a room.
+// An empty filter is returned if the room has no default filter.
+func (c *Client) roomFilter(ctx context.Context, roomID mid.RoomID) *alertmanager.AlertFilter {
+	for room, filter := range c.roomFilters {
+		if id, err := c.ResolveRoom(ctx, room); err == nil && id == roomID {
+			f := *filter
+
+			return &f
+		}
+	}
+
+	return &alertmanager.AlertFilter{}
+}
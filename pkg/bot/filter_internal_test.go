@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAlertFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		matchers string
+		receiver string
+		none     bool
+		err      bool
+		target   error
+	}{
+		{name: "empty", none: true},
+		{name: "blank", args: []string{" "}, none: true},
+		{name: "matcher", args: []string{"alertname=Test"}, matchers: `{alertname="Test"}`},
+		{
+			name:     "matchers",
+			args:     []string{`{severity=~"critical|warning",`, `team!="db"}`},
+			matchers: `{severity=~"critical|warning",team!="db"}`,
+		},
+		{name: "receiver", args: []string{"receiver", "matrix-.*"}, receiver: "matrix-.*"},
+		{
+			name:     "matchers and receiver",
+			args:     []string{"receiver", "matrix", "severity=critical"},
+			matchers: `{severity="critical"}`,
+			receiver: "matrix",
+		},
+		{
+			name:     "receiver after matchers",
+			args:     []string{"severity=critical", "receiver", "matrix"},
+			matchers: `{severity="critical"}`,
+			receiver: "matrix",
+		},
+		{name: "last receiver", args: []string{"receiver", "a", "receiver", "b"}, receiver: "b"},
+		{name: "missing receiver", args: []string{"severity=critical", "receiver"}, err: true, target: errMissingReceiver},
+		{name: "invalid matchers", args: []string{"severity=~("}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			filter, err := parseAlertFilter(test.args)
+
+			switch {
+			case test.err:
+				if err == nil || test.target != nil && !errors.Is(err, test.target) {
+					t.Errorf("parseAlertFilter(%q) error = %v, want %v", test.args, err, test.target)
+				}
+			case err != nil:
+				t.Errorf("parseAlertFilter(%q) error = %v", test.args, err)
+			case test.none:
+				if filter != nil {
+					t.Errorf("parseAlertFilter(%q) = %+v, want nil", test.args, filter)
+				}
+			case filter == nil:
+				t.Errorf("parseAlertFilter(%q) = nil", test.args)
+			default:
+				var matchers string
+				if len(filter.Matchers) > 0 {
+					matchers = filter.Matchers.String()
+				}
+
+				if matchers != test.matchers || filter.Receiver != test.receiver {
+					t.Errorf("parseAlertFilter(%q) = %s receiver %q, want %s receiver %q",
+						test.args, matchers, filter.Receiver, test.matchers, test.receiver)
+				}
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package bot
 import (
 	"bytes"
 	_ "embed"
+	"fmt"
 	html "html/template"
+	"slices"
 	"strings"
 	text "text/template"
 
@@ -11,6 +13,7 @@ import (
 
 	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
 	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
 )
 
 // Default alert template values.
@@ -35,6 +38,7 @@ var (
 		"error":       "red",
 		"resolved":    "green",
 		"silenced":    "gray",
+		"inhibited":   "gray",
 	}
 
 	DefaultIcons = map[string]string{ //nolint:gochecknoglobals // used as constant
@@ -46,6 +50,7 @@ var (
 		"error":       "🚨",
 		"resolved":    "✅",
 		"silenced":    "🔕",
+		"inhibited":   "⛔",
 	}
 )
 
@@ -136,6 +141,59 @@ func (f *Formatter) FormatAlerts(alerts []*alertmanager.Alert, showLabels bool)
 	return plainBuilder.String(), htmlBuilder.String()
 }
 
+// FormatAlertGroups formats alert groups as plain text and HTML.
+// Each group is shown with its receiver, labels and number of alerts.
+// Only the first alerts are shown for groups with more than maxAlerts alerts.
+func (f *Formatter) FormatAlertGroups(groups []*alertmanager.AlertGroup, showLabels bool,
+	maxAlerts int,
+) (plainContent, htmlContent string) {
+	var plainBuilder, htmlBuilder strings.Builder
+
+	for _, g := range groups {
+		labels := alertmanager.LabelMatchers(g.Labels).String()
+
+		fmt.Fprintf(&plainBuilder, "📂 %s %s: %d alerts\n", g.Receiver, labels, len(g.Alerts))
+		fmt.Fprintf(&htmlBuilder, "<b>📂 %s</b> <code>%s</code>: %d alerts<br/>",
+			html.HTMLEscapeString(g.Receiver), html.HTMLEscapeString(labels), len(g.Alerts))
+
+		alerts := g.Alerts[:min(len(g.Alerts), maxAlerts)]
+		plain, htm := f.FormatAlerts(alerts, showLabels)
+
+		plainBuilder.WriteString(plain)
+		htmlBuilder.WriteString(htm)
+
+		if n := len(g.Alerts) - len(alerts); n > 0 {
+			fmt.Fprintf(&plainBuilder, "… and %d more\n", n)
+			fmt.Fprintf(&htmlBuilder, "<i>… and %d more</i><br/>", n)
+		}
+
+		plainBuilder.WriteString("\n")
+		htmlBuilder.WriteString("<br/>")
+	}
+
+	return plainBuilder.String(), htmlBuilder.String()
+}
+
+// FormatAcks formats the users that acknowledged alerts as plain text and HTML.
+// Empty strings are returned if there are no acknowledgements.
+func (f *Formatter) FormatAcks(acks []*store.Ack) (plainContent, htmlContent string) {
+	users := make([]string, 0, len(acks))
+
+	for _, ack := range acks {
+		if !slices.Contains(users, ack.UserID.String()) {
+			users = append(users, ack.UserID.String())
+		}
+	}
+
+	if len(users) == 0 {
+		return "", ""
+	}
+
+	plainContent = "Acknowledged by " + strings.Join(users, ", ")
+
+	return plainContent + "\n", "<i>" + html.HTMLEscapeString(plainContent) + "</i><br/>"
+}
+
 // FormatSilences formats silences as Markdown.
 func (f *Formatter) FormatSilences(silences []alertmanager.Silence, state string) (md string) {
 	buf := &bytes.Buffer{}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"maunium.net/go/mautrix"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// loginDeviceName is the name of devices created by logging in.
+const loginDeviceName = "Alertmanager bot"
+
+// LoginConfig contains the credentials the bot logs in with
+// when no access token is configured, or when the access token is no longer valid.
+type LoginConfig struct {
+	// Password is the password of the bot user.
+	Password string
+
+	// SharedSecretAuth is the secret of the devture shared secret authenticator module of the homeserver
+	// (`com.devture.shared_secret_auth`). This is not the registration shared secret of the homeserver.
+	// It is used instead of the password if set.
+	SharedSecretAuth string
+}
+
+// startSession starts a session with a valid access token.
+// The stored session is preferred over the configured access token.
+// The bot logs in if neither is available, or if the access token is no longer valid.
+func (c *Client) startSession(ctx context.Context) error {
+	session, err := c.store.Session(ctx, c.Matrix.Client.UserID)
+
+	switch {
+	case err == nil:
+		c.Matrix.Client.AccessToken = session.AccessToken
+		c.Matrix.Client.DeviceID = session.DeviceID
+	case !errors.Is(err, store.ErrNotFound):
+		return fmt.Errorf("error loading session: %w", err)
+	case c.Matrix.Client.AccessToken == "":
+		return c.login(ctx)
+	}
+
+	resp, err := c.Matrix.Client.Whoami(ctx)
+
+	switch {
+	case errors.Is(err, mautrix.MUnknownToken):
+		log.Printf("Access token is no longer valid, logging in again")
+
+		return c.login(ctx)
+	case err != nil:
+		return fmt.Errorf("error checking access token: %w", err)
+	}
+
+	if c.Matrix.Client.DeviceID == "" {
+		c.Matrix.Client.DeviceID = resp.DeviceID
+	}
+
+	return nil
+}
+
+// login logs in using the configured credentials, and stores the new session.
+// The current device is reused if known, so that its encryption keys remain valid.
+func (c *Client) login(ctx context.Context) error {
+	req := &mautrix.ReqLogin{
+		Type: mautrix.AuthTypePassword,
+		Identifier: mautrix.UserIdentifier{
+			Type: mautrix.IdentifierTypeUser,
+			User: c.Matrix.Client.UserID.String(),
+		},
+		Password:                 c.loginConfig.Password,
+		DeviceID:                 c.Matrix.Client.DeviceID,
+		InitialDeviceDisplayName: loginDeviceName,
+		StoreCredentials:         true,
+	}
+
+	if c.loginConfig.SharedSecretAuth != "" {
+		mac := hmac.New(sha512.New, []byte(c.loginConfig.SharedSecretAuth))
+		mac.Write([]byte(c.Matrix.Client.UserID))
+
+		req.Type = mautrix.AuthTypeDevtureSharedSecret
+		req.Password = ""
+		req.Token = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	resp, err := c.Matrix.Client.Login(ctx, req)
+	if err != nil {
+		return fmt.Errorf("error logging in: %w", err)
+	}
+
+	log.Printf("Logged in as %s with device %s", resp.UserID, resp.DeviceID)
+
+	err = c.store.SetSession(ctx, &store.Session{
+		UserID:      resp.UserID,
+		DeviceID:    resp.DeviceID,
+		AccessToken: resp.AccessToken,
+	})
+	if err != nil {
+		return fmt.Errorf("error storing session: %w", err)
+	}
+
+	return nil
+}
+
+// sync syncs with the homeserver until the context is canceled or an error occurs.
+// The bot logs in again if the access token is no longer valid and credentials are configured.
+func (c *Client) sync(ctx context.Context) error {
+	for {
+		err := c.Matrix.Run(ctx)
+		if c.loginConfig == nil || !errors.Is(err, mautrix.MUnknownToken) {
+			return err //nolint:wrapcheck // wrapped by caller
+		}
+
+		log.Printf("Access token is no longer valid, logging in again")
+
+		if err := c.login(ctx); err != nil {
+			return err
+		}
+	}
+}
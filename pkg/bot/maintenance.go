@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/robfig/cron/v3"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// DefaultMaintenanceLookahead is the default duration ahead of a maintenance window that its silence is created.
+const DefaultMaintenanceLookahead = 24 * time.Hour
+
+// maintenanceInterval is the interval at which silences for maintenance windows are created.
+const maintenanceInterval = 5 * time.Minute
+
+// maintenanceListCount is the number of upcoming occurrences listed per maintenance window.
+const maintenanceListCount = 3
+
+// MaintenanceWindow contains the configuration of a recurring maintenance window.
+type MaintenanceWindow struct {
+	Name     string          // Name identifying the window.
+	Schedule cron.Schedule   // Schedule of the start of the window.
+	Location *time.Location  // Time zone the window is shown in (optional).
+	Duration time.Duration   // Duration of the window.
+	Matchers labels.Matchers // Matchers of the silence for the window.
+	Comment  string          // Comment of the silence for the window (optional).
+	Rooms    []string        // Rooms to announce the silences for the window in (optional).
+
+	// Alertmanager is the name of the Alertmanager backend the silences are created in (optional).
+	Alertmanager string
+}
+
+// occurrences returns the start times of all occurrences of the window that have not ended before from,
+// and start before to.
+func (w *MaintenanceWindow) occurrences(from, to time.Time) []time.Time {
+	var starts []time.Time
+
+	if w.Location != nil {
+		from = from.In(w.Location)
+	}
+
+	for t := w.Schedule.Next(from.Add(-w.Duration)); !t.IsZero() && t.Before(to); t = w.Schedule.Next(t) {
+		starts = append(starts, t)
+	}
+
+	return starts
+}
+
+// runMaintenance periodically creates silences for upcoming maintenance windows until the context is canceled.
+func (c *Client) runMaintenance(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		c.scheduleMaintenance(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleMaintenance creates silences for the maintenance windows starting within the lookahead duration.
+// Windows that already have a silence are skipped.
+func (c *Client) scheduleMaintenance(ctx context.Context, now time.Time) {
+	if err := c.store.PruneMaintenanceWindows(ctx, now); err != nil {
+		log.Printf("Error pruning maintenance windows: %s", err)
+	}
+
+	for _, w := range c.maintenance {
+		for _, start := range w.occurrences(now, now.Add(c.maintenanceLookahead)) {
+			_, err := c.store.MaintenanceWindow(ctx, w.Name, start)
+			if err == nil {
+				continue
+			}
+
+			if !errors.Is(err, store.ErrNotFound) {
+				log.Printf("Error loading maintenance window %q: %s", w.Name, err)
+
+				continue
+			}
+
+			if err := c.createMaintenanceSilence(WithAlertmanager(ctx, w.Alertmanager), w, start); err != nil {
+				log.Printf("Error creating silence for maintenance window %q: %s", w.Name, err)
+			}
+		}
+	}
+}
+
+// createMaintenanceSilence creates the silence for an occurrence of a maintenance window, and announces it.
+func (c *Client) createMaintenanceSilence(ctx context.Context, w *MaintenanceWindow, start time.Time) error {
+	end := start.Add(w.Duration)
+	silence := alertmanager.Silence{
+		GettableSilence: &models.GettableSilence{
+			Silence: models.Silence{
+				StartsAt:  util.PtrTo(strfmt.DateTime(start)),
+				EndsAt:    util.PtrTo(strfmt.DateTime(end)),
+				CreatedBy: util.PtrTo(c.Matrix.Client.UserID.String()),
+				Comment:   util.PtrTo(cmp.Or(w.Comment, "Maintenance window "+w.Name)),
+			},
+		},
+	}
+	silence.SetMatchers(w.Matchers)
+
+	// The silence may exist if the window could not be stored, or was created by another instance
+	id, err := c.existingMaintenanceSilence(ctx, w, start, end)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		log.Printf("Found silence %s for maintenance window %q starting at %s", id, w.Name, start)
+
+		return c.storeMaintenanceWindow(ctx, w, start, end, id)
+	}
+
+	id, err = c.backend(ctx).CreateSilence(ctx, silence)
+
+	c.audit(ctx, &store.AuditEntry{
+		Action:    "maintenance.create",
+		SilenceID: id,
+		Matchers:  w.Matchers.String(),
+		Details:   fmt.Sprintf("%s starting at %s", w.Name, start.Format(silenceTimeFormat)),
+		Error:     auditError(err),
+	})
+
+	if err != nil {
+		return err //nolint:wrapcheck // transparent wrapper
+	}
+
+	log.Printf("Created silence %s for maintenance window %q starting at %s", id, w.Name, start)
+
+	// The silence is announced even if it cannot be stored, as it is found in Alertmanager next time
+	if err := c.storeMaintenanceWindow(ctx, w, start, end, id); err != nil {
+		log.Printf("Silence %s for maintenance window %q: %s", id, w.Name, err)
+	}
+
+	msg := fmt.Sprintf("🔧 Maintenance window **%s** scheduled from %s until %s.  \nSilence *%s* matches `%s`",
+		w.Name, start.Format(silenceTimeFormat), end.Format(silenceTimeFormat), id, w.Matchers)
+
+	for _, room := range w.Rooms {
+		roomID, err := c.ResolveRoom(ctx, room)
+		if err != nil {
+			log.Printf("Error announcing maintenance window %q: %s", w.Name, err)
+
+			continue
+		}
+
+		if _, err := c.Matrix.NewRoom(roomID).SendMarkdown(ctx, msg); err != nil {
+			log.Printf("Error announcing maintenance window %q in %s: %s", w.Name, roomID, err)
+		}
+	}
+
+	return nil
+}
+
+// existingMaintenanceSilence returns the ID of the silence in Alertmanager for an occurrence of a maintenance window,
+// or an empty string if there is none.
+// This is a silence that is not expired, and has the same matchers, start and end as the window.
+func (c *Client) existingMaintenanceSilence(ctx context.Context, w *MaintenanceWindow,
+	start, end time.Time,
+) (string, error) {
+	silences, err := c.backend(ctx).GetSilences(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving existing silences: %w", err)
+	}
+
+	want := matcherStrings(w.Matchers)
+
+	for _, s := range silences {
+		if s.Status() != models.SilenceStatusStateExpired && s.StartsAt().Equal(start) &&
+			s.EndsAt().Equal(end) && slices.Equal(matcherStrings(s.Matchers()), want) {
+			return s.ID(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// matcherStrings returns the sorted string representations of matchers, for comparing them regardless of order.
+func matcherStrings(matchers labels.Matchers) []string {
+	strs := make([]string, len(matchers))
+	for i, m := range matchers {
+		strs[i] = m.String()
+	}
+
+	slices.Sort(strs)
+
+	return strs
+}
+
+// storeMaintenanceWindow records the silence of an occurrence of a maintenance window.
+func (c *Client) storeMaintenanceWindow(ctx context.Context, w *MaintenanceWindow, start, end time.Time,
+	silenceID string,
+) error {
+	err := c.store.SetMaintenanceWindow(ctx, &store.MaintenanceWindow{
+		Name:      w.Name,
+		StartsAt:  start,
+		EndsAt:    end,
+		SilenceID: silenceID,
+	})
+	if err != nil {
+		return fmt.Errorf("error storing maintenance window: %w", err)
+	}
+
+	return nil
+}
+
+// Maintenance returns a Markdown formatted list of upcoming maintenance windows.
+func (c *Client) Maintenance(ctx context.Context) string {
+	if len(c.maintenance) == 0 {
+		return "No maintenance windows configured"
+	}
+
+	var sb strings.Builder
+
+	now := time.Now()
+
+	for _, w := range c.maintenance {
+		fmt.Fprintf(&sb, "**🔧 %s**  \nMatches: `%s`  \n", w.Name, w.Matchers)
+
+		starts := w.occurrences(now, now.AddDate(1, 0, 0))
+		if len(starts) == 0 {
+			sb.WriteString("No upcoming windows\n\n")
+
+			continue
+		}
+
+		for _, start := range starts[:min(len(starts), maintenanceListCount)] {
+			fmt.Fprintf(&sb, "- %s until %s", start.Format(silenceTimeFormat),
+				start.Add(w.Duration).Format(silenceTimeFormat))
+
+			if sw, err := c.store.MaintenanceWindow(ctx, w.Name, start); err == nil {
+				fmt.Fprintf(&sb, " (silence *%s*)", sw.SilenceID)
+			}
+
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -6,6 +6,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,26 +22,125 @@ import (
 
 	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
 	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
 )
 
 var errNilClientConfig = errors.New("client config cannot be nil")
 
 // ClientConfig contains the configuration for the client.
 type ClientConfig struct {
-	Homeserver      string // Matrix homeserver URL.
-	UserID          string // Matrix user ID.
-	Token           string // Matrix token.
-	MessageType     string // Matrix NewMessage type (optional).
-	Rooms           string // Comma-separated list of matrix rooms (optional).
-	AlertManagerURL string // URL to the Alert Manager API.
+	Homeserver       string        // Matrix homeserver URL.
+	UserID           string        // Matrix user ID.
+	Token            string        // Matrix token (optional if Login is set).
+	MessageType      string        // Matrix NewMessage type (optional).
+	Rooms            []string      // List of Matrix rooms (optional).
+	AlertManagerURL  string        // URL to the Alert Manager API.
+	AlertManagerURLs []string      // URLs to the nodes of an Alert Manager cluster (optional, overrides AlertManagerURL).
+	AliasTTL         time.Duration // Duration resolved room aliases are cached for (optional).
+	EditMessages     bool          // Edit notifications for alert groups instead of sending new messages.
+	EditMaxAge       time.Duration // Maximum age of notifications that are edited or replied to (optional).
+	Threads          bool          // Send notifications for alert groups in threads.
+	Store            store.Store   // Store for the state of the bot (optional, defaults to in-memory).
+
+	// Reactions contains the actions for reactions to notifications, by reaction key (emoji).
+	Reactions map[string]Reaction
+
+	// ConfirmThreshold is the number of alerts above which new silences must be confirmed.
+	// Confirmation is disabled when zero.
+	ConfirmThreshold int
+
+	// Location is the default time zone for scheduled silences (optional, defaults to local time).
+	Location *time.Location
+
+	// UserLocations contains the time zones for scheduled silences of users.
+	UserLocations map[mid.UserID]*time.Location
+
+	// RoomLocations contains the time zones for scheduled silences in rooms, by room ID or alias.
+	RoomLocations map[string]*time.Location
+
+	// AlertManagerHTTPClient is the HTTP client for Alert Manager API requests (optional).
+	// This allows configuring authentication, TLS and proxies.
+	AlertManagerHTTPClient *http.Client
+
+	// ClusterPeers is the expected number of peers in the Alertmanager cluster (optional).
+	// The cluster is shown as degraded when it has fewer peers.
+	ClusterPeers int
+
+	// Alertmanagers contains additional Alertmanager backends by name (optional).
+	// These can be selected in commands using `@name`.
+	Alertmanagers map[string]*alertmanager.Client
+
+	// RoomAlertmanagers contains the names of the default Alertmanager backends of rooms, by room ID or alias.
+	RoomAlertmanagers map[string]string
+
+	// ReadPermission is the permission required for all commands (optional).
+	ReadPermission Permission
+
+	// WritePermission is the permission required for commands and reactions that change silences or alerts (optional).
+	WritePermission Permission
+
+	// RoomFilters contains the default filters for listing alerts in rooms, by room ID or alias.
+	RoomFilters map[string]*alertmanager.AlertFilter
+
+	// Maintenance contains recurring maintenance windows that silences are created for.
+	Maintenance []*MaintenanceWindow
+
+	// MaintenanceLookahead is the duration ahead of a maintenance window that its silence is created (optional).
+	MaintenanceLookahead time.Duration
+
+	// AuditLogger receives the entries of the audit trail as structured log messages (optional).
+	AuditLogger *slog.Logger
+
+	// AuditRoom is the room ID or alias of a room that the entries of the audit trail are posted in (optional).
+	AuditRoom string
+
+	// Login contains the credentials to log in with when no token is set, or when the token is revoked (optional).
+	// The session is persisted in the store, and preferred over Token.
+	Login *LoginConfig
+
+	// Crypto contains the configuration of end-to-end encryption (optional).
+	// Encryption is disabled when nil.
+	Crypto *CryptoConfig
 }
 
+// groupMaxAlerts is the maximum number of alerts shown per alert group.
+const groupMaxAlerts = 5
+
 // Client represents an Alertmanager/Matrix client.
 type Client struct {
 	Matrix       *bot.Client
 	Alertmanager *alertmanager.Client
 	Formatter    *Formatter
 	startTime    time.Time
+	aliases      *aliasCache
+
+	store        store.Store
+	editMessages bool
+	editMaxAge   time.Duration
+	threads      bool
+	reactions    map[string]Reaction
+
+	confirmThreshold int
+	confirmations    *confirmationStore
+
+	defaultLocation *time.Location
+	userLocations   map[mid.UserID]*time.Location
+	roomLocations   map[string]*time.Location
+	roomFilters     map[string]*alertmanager.AlertFilter
+
+	alertmanagers     map[string]*alertmanager.Client
+	roomAlertmanagers map[string]string
+
+	readPermission  Permission
+	writePermission Permission
+
+	maintenance          []*MaintenanceWindow
+	maintenanceLookahead time.Duration
+
+	auditLogger *slog.Logger
+	auditRoom   string
+
+	loginConfig *LoginConfig
 }
 
 // NewClient creates and starts a new Alertmanager/Matrix client.
@@ -49,6 +152,35 @@ func NewClient(config *ClientConfig, formatter *Formatter) (client *Client, err
 	client = &Client{
 		Formatter: formatter,
 		startTime: time.Now(),
+		aliases:   newAliasCache(cmp.Or(config.AliasTTL, DefaultAliasTTL)),
+
+		store:        config.Store,
+		editMessages: config.EditMessages,
+		editMaxAge:   cmp.Or(config.EditMaxAge, DefaultEditMaxAge),
+		threads:      config.Threads,
+		reactions:    config.Reactions,
+
+		confirmThreshold: config.ConfirmThreshold,
+		confirmations:    newConfirmationStore(),
+
+		defaultLocation: cmp.Or(config.Location, time.Local),
+		userLocations:   config.UserLocations,
+		roomLocations:   config.RoomLocations,
+		roomFilters:     config.RoomFilters,
+
+		alertmanagers:     config.Alertmanagers,
+		roomAlertmanagers: config.RoomAlertmanagers,
+
+		readPermission:  config.ReadPermission,
+		writePermission: config.WritePermission,
+
+		maintenance:          config.Maintenance,
+		maintenanceLookahead: cmp.Or(config.MaintenanceLookahead, DefaultMaintenanceLookahead),
+
+		auditLogger: config.AuditLogger,
+		auditRoom:   config.AuditRoom,
+
+		loginConfig: config.Login,
 	}
 
 	// Ensure a formatter is set
@@ -56,17 +188,28 @@ func NewClient(config *ClientConfig, formatter *Formatter) (client *Client, err
 		client.Formatter = NewFormatter("", "", nil, nil)
 	}
 
+	// Ensure a store is set
+	if client.store == nil {
+		client.store = store.NewMemory()
+	}
+
 	// Create Alertmanager client
-	client.Alertmanager, err = alertmanager.NewClient(config.AlertManagerURL)
+	urls := config.AlertManagerURLs
+	if len(urls) == 0 {
+		urls = []string{config.AlertManagerURL}
+	}
+
+	client.Alertmanager, err = alertmanager.NewClientWithHTTPClient(config.AlertManagerHTTPClient, urls...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating Alertmanager client: %w", err)
 	}
 
-	// Matrix bot config
+	client.Alertmanager.ExpectedPeers = config.ClusterPeers
+
+	// Matrix bot config, commands are handled by handleMessage
 	matrixConfig := &bot.ClientConfig{
 		MessageType:      mevent.MessageType(config.MessageType),
-		CommandPrefixes:  []string{"!alert", "!alertmanager"},
-		IgnoreHighlights: false,
+		IgnoreHighlights: true,
 	}
 
 	// Create Matrix client
@@ -75,53 +218,146 @@ func NewClient(config *ClientConfig, formatter *Formatter) (client *Client, err
 		return nil, fmt.Errorf("error creating Matrix client: %w", err)
 	}
 
-	// Create room list
-	if config.Rooms != "" {
-		for _, room := range strings.Split(config.Rooms, ",") {
-			matrixConfig.AllowedRooms = append(matrixConfig.AllowedRooms, mid.RoomID(room))
+	// Continue syncing where the previous run left off
+	client.Matrix.Client.Store = client.store
+
+	if config.Crypto != nil {
+		client.Matrix.Client.DeviceID = config.Crypto.DeviceID
+	}
+
+	// Log in or continue the previous session, before joining rooms or initializing encryption
+	if config.Login != nil {
+		if err := client.startSession(context.Background()); err != nil {
+			return nil, err
 		}
 	}
 
-	// Register commands
-	client.Matrix.SetCommand("", client.listOnlyCommand())
-	client.Matrix.SetCommand("list", client.listCommand())
-	client.Matrix.SetCommand("silence", client.silenceCommand())
+	// Enable encryption before any messages are sent
+	if config.Crypto != nil {
+		if err := client.initCrypto(context.Background(), config.Crypto); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create room list
+	for _, room := range config.Rooms {
+		matrixConfig.AllowedRooms = append(matrixConfig.AllowedRooms, mid.RoomID(room))
+	}
+
+	// Register event handlers
+	client.Matrix.SetMessageHandler(mevent.EventMessage, client.handleMessage)
+	client.Matrix.SetMessageHandler(mevent.EventRedaction, client.handleRedaction)
+	client.Matrix.SetMessageHandler(mevent.EventReaction, client.handleReaction)
 
 	return client, nil
 }
 
-// mainCommand returns the `alert` bot command.
-func (c *Client) listOnlyCommand() *bot.Command {
+// commands returns the bot commands for a request.
+func (c *Client) commands(req *request) map[string]*bot.Command {
+	return map[string]*bot.Command{
+		"":        c.listOnlyCommand(req),
+		"list":    c.listCommand(req),
+		"silence": c.silenceCommand(req),
+		"groups": {
+			Summary: "Show active alerts by alert group.",
+			Description: "Show active alerts by alert group, with the receiver and labels of each group.\n\n" +
+				"The groups can be filtered like the alerts of the `list` command.\n",
+			MessageHandler: func(_ mid.UserID, _ string, args ...string) *bot.Message {
+				filter, err := c.alertFilter(req, false, args)
+				if err != nil {
+					return bot.NewTextMessage(err.Error())
+				}
+
+				return c.AlertGroups(req.ctx, filter)
+			},
+		},
+		"status": {
+			Summary: "Show the status of Alertmanager.",
+			MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
+				return c.Status(req.ctx)
+			},
+		},
+		"explain": {
+			Summary: "Explain why an alert does not notify.",
+			Description: "Show the silences and inhibiting alerts that suppress an alert:\n" +
+				"```\nexplain <fingerprint>\n```\n",
+			MessageHandler: func(_ mid.UserID, _ string, args ...string) *bot.Message {
+				if len(args) != 1 {
+					return bot.NewTextMessage("Usage: explain <fingerprint>")
+				}
+
+				return bot.NewMarkdownMessage(c.Explain(req.ctx, args[0]))
+			},
+		},
+		"maintenance": {
+			Summary: "Show upcoming maintenance windows.",
+			MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
+				return bot.NewMarkdownMessage(c.Maintenance(req.ctx))
+			},
+		},
+		"audit": {
+			Summary: "Show the last actions taken by the bot.",
+			Description: "Show the last actions that changed silences or alerts, for example:\n" +
+				"```\naudit 20\n```\n" +
+				"Only actions requested in the current room are shown, " +
+				"except in the audit room and to users with the write permission.\n",
+			MessageHandler: func(_ mid.UserID, _ string, args ...string) *bot.Message {
+				n := defaultAuditCount
+
+				if len(args) > 0 {
+					var err error
+
+					n, err = strconv.Atoi(args[0])
+					if err != nil || n <= 0 {
+						return bot.NewTextMessage("Usage: audit [count]")
+					}
+				}
+
+				return bot.NewMarkdownMessage(c.Audit(req.ctx, c.auditScope(req), min(n, maxAuditCount)))
+			},
+		},
+	}
+}
+
+// listOnlyCommand returns the `alert` bot command.
+func (c *Client) listOnlyCommand(req *request) *bot.Command {
 	return &bot.Command{
 		Summary: "Show active alerts.",
-		MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-			return c.Alerts(context.Background(), false, false)
-		},
+		Description: "Show active alerts.\n\n" +
+			"The alerts can be filtered by label matchers and receiver, for example:\n" +
+			"```\nlist severity=\"critical\",team=\"db\" receiver team-db\n```\n" +
+			"Without a filter, the alerts of the notification replied to or the default filter of the room are used.\n",
+		MessageHandler: c.listHandler(req, false, false),
 	}
 }
 
 // listCommand returns the `list` bot command.
-func (c *Client) listCommand() *bot.Command {
-	cmd := c.listOnlyCommand()
+func (c *Client) listCommand(req *request) *bot.Command {
+	cmd := c.listOnlyCommand(req)
 	cmd.Subcommands = map[string]*bot.Command{
 		"all": {
-			Summary: "Show active and silenced alerts.",
-			MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-				return c.Alerts(context.Background(), true, false)
-			},
+			Summary:        "Show active and silenced alerts.",
+			MessageHandler: c.listHandler(req, true, false),
 			Subcommands: map[string]*bot.Command{
 				"labels": {
-					Summary: "Shows label of active and silenced alerts.",
-					MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-						return c.Alerts(context.Background(), true, true)
-					},
+					Summary:        "Shows label of active and silenced alerts.",
+					MessageHandler: c.listHandler(req, true, true),
 				},
 			},
 		},
 		"labels": {
-			Summary: "Show labels of active alerts.",
-			MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-				return c.Alerts(context.Background(), false, true)
+			Summary:        "Show labels of active alerts.",
+			MessageHandler: c.listHandler(req, false, true),
+		},
+		"inhibited": {
+			Summary: "Show inhibited alerts.",
+			MessageHandler: func(_ mid.UserID, _ string, args ...string) *bot.Message {
+				filter, err := c.alertFilter(req, false, args)
+				if err != nil {
+					return bot.NewTextMessage(err.Error())
+				}
+
+				return c.InhibitedAlerts(req.ctx, filter, false)
 			},
 		},
 	}
@@ -129,24 +365,49 @@ func (c *Client) listCommand() *bot.Command {
 	return cmd
 }
 
+// listHandler returns the message handler of a list command.
+func (c *Client) listHandler(req *request, silenced, showLabels bool) func(mid.UserID, string, ...string) *bot.Message {
+	return func(_ mid.UserID, _ string, args ...string) *bot.Message {
+		filter, err := c.alertFilter(req, silenced, args)
+		if err != nil {
+			return bot.NewTextMessage(err.Error())
+		}
+
+		return c.Alerts(req.ctx, filter, showLabels)
+	}
+}
+
 // silenceCommand returns the `silence` command.
-func (c *Client) silenceCommand() *bot.Command {
+func (c *Client) silenceCommand(req *request) *bot.Command {
 	return &bot.Command{
 		Summary: "Show active silences.",
-		MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-			return bot.NewMarkdownMessage(c.Silences(context.Background(), "active"))
+		Description: "Show active silences.\n\n" +
+			"When replying to an alert notification, or within its thread, silence its alerts instead:\n" +
+			"```\nsilence 2h [group|common] [comment]\n```\n" +
+			"The alerts of the notification are silenced exactly by default.\n" +
+			"Use `group` to silence the alert group, or `common` to silence the labels the alerts have in common.\n",
+		MessageHandler: func(sender mid.UserID, _ string, args ...string) *bot.Message {
+			if len(args) > 0 && (req.replyTo != "" || req.notification != nil) {
+				if denied := c.checkWrite(req, "create silences"); denied != nil {
+					return denied
+				}
+
+				return c.silenceReply(req, sender, args)
+			}
+
+			return bot.NewMarkdownMessage(c.Silences(req.ctx, "active"))
 		},
 		Subcommands: map[string]*bot.Command{
 			"pending": {
 				Summary: "Show pending silences.",
 				MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-					return bot.NewMarkdownMessage(c.Silences(context.Background(), "pending"))
+					return bot.NewMarkdownMessage(c.Silences(req.ctx, "pending"))
 				},
 			},
 			"expired": {
 				Summary: "Shows expired silences.",
 				MessageHandler: func(_ mid.UserID, _ string, _ ...string) *bot.Message {
-					return bot.NewMarkdownMessage(c.Silences(context.Background(), "expired"))
+					return bot.NewMarkdownMessage(c.Silences(req.ctx, "expired"))
 				},
 			},
 			"add": {
@@ -155,28 +416,150 @@ func (c *Client) silenceCommand() *bot.Command {
 					"A matcher matches job labels, for example: \n" +
 					"```\nsilence add 1h job=\"test\",target=~\"test.*\"\n```\n" +
 					"Alternative, an alert fingerprint can be given to match all labels of that alert, for example:\n" +
-					"```\nsilence add 1h 04e45af092081699\n```\n",
-				MessageHandler: func(sender mid.UserID, _ string, args ...string) *bot.Message {
-					if len(args) <= 1 {
+					"```\nsilence add 1h 04e45af092081699\n```\n" +
+					"Within the thread of an alert notification, the matchers default to the labels of the alert group, " +
+					"and words before any matchers are used as the comment:\n" +
+					"```\nsilence add 2h Maintenance\n```\n" +
+					"A silence can be scheduled using `from` or `at`, followed by a time and `for <duration>`:\n" +
+					"```\nsilence add from 2026-10-20T22:00 for 3h job=\"test\"\n" +
+					"silence add at 22:00 for 2h job=\"test\"\n" +
+					"silence add at tomorrow 06:00 for 1h job=\"test\"\n```\n" +
+					"Times are in the time zone configured for the user or room.\n\n" +
+					"The alerts matched by the silence are shown. " +
+					"If too many alerts would be matched, the silence must be confirmed first.\n",
+				MessageHandler: c.write(req, "create silences", func(sender mid.UserID, _ string, args ...string) *bot.Message {
+					var startsAt time.Time
+
+					if len(args) > 0 && (args[0] == "from" || args[0] == "at") {
+						now := time.Now().In(c.location(req.ctx, sender, req.roomID))
+
+						var (
+							duration string
+							err      error
+						)
+
+						startsAt, duration, args, err = parseSchedule(args[1:], now)
+						if err != nil {
+							return bot.NewTextMessage(err.Error())
+						}
+
+						args = append([]string{duration}, args...)
+					}
+
+					if len(args) == 0 {
 						return bot.NewTextMessage("Insufficient arguments.")
 					}
 
 					matchers, comments := splitArgs(args[1:])
+					if req.notification != nil {
+						matchers, comments = splitThreadArgs(args[1:])
+					}
+
+					if matchers == "" && req.notification != nil {
+						matchers = req.notification.Matchers().String()
+					}
 
-					return bot.NewMarkdownMessage(c.NewSilence(context.Background(),
-						sender.String(), args[0], matchers, comments))
+					if matchers == "" {
+						return bot.NewTextMessage("Insufficient arguments.")
+					}
+
+					silence, err := c.newSilence(req.ctx, sender.String(), startsAt, args[0], matchers, comments)
+					if err != nil {
+						return bot.NewTextMessage(err.Error())
+					}
+
+					return c.addSilence(req, silence)
+				}),
+			},
+			"preview": {
+				Summary: "Show the alerts that a silence would match.",
+				Description: "Show the alerts that a silence with the given `matcher` or `fingerprint` would match.\n\n" +
+					"Within the thread of an alert notification, the matchers default to the labels of the alert group.\n",
+				MessageHandler: func(_ mid.UserID, _ string, args ...string) *bot.Message {
+					matchers, _ := splitArgs(args)
+					if matchers == "" && req.notification != nil {
+						matchers = req.notification.Matchers().String()
+					}
+
+					if matchers == "" {
+						return bot.NewTextMessage("Insufficient arguments.")
+					}
+
+					return c.PreviewSilence(req.ctx, matchers)
 				},
 			},
+			"extend": {
+				Summary:     "Extend a silence by a duration.",
+				Description: "Extend a silence by a duration, for example:\n```\nsilence extend <id> 2h\n```\n",
+				MessageHandler: c.write(req, "change silences", func(sender mid.UserID, _ string, args ...string) *bot.Message {
+					if len(args) != 2 { //nolint:mnd // ID and duration
+						return bot.NewTextMessage("Usage: silence extend <id> <duration>")
+					}
+
+					return bot.NewMarkdownMessage(c.ExtendSilence(req.ctx, sender.String(), args[0], args[1]))
+				}),
+			},
+			"edit": {
+				Summary:     "Replace the matchers of a silence.",
+				Description: "Replace the matchers of a silence, for example:\n```\nsilence edit <id> job=\"test\"\n```\n",
+				MessageHandler: c.write(req, "change silences", func(sender mid.UserID, _ string, args ...string) *bot.Message {
+					if len(args) < 2 { //nolint:mnd // ID and matchers
+						return bot.NewTextMessage("Usage: silence edit <id> <matchers>")
+					}
+
+					matchers, _ := splitArgs(args[1:])
+
+					return bot.NewMarkdownMessage(c.EditSilence(req.ctx, sender.String(), args[0], matchers))
+				}),
+			},
+			"comment": {
+				Summary: "Replace the comment of a silence.",
+				MessageHandler: c.write(req, "change silences", func(sender mid.UserID, _ string, args ...string) *bot.Message {
+					if len(args) < 2 { //nolint:mnd // ID and comment
+						return bot.NewTextMessage("Usage: silence comment <id> <comment>")
+					}
+
+					words, lines := splitArgs(args[1:])
+
+					return bot.NewMarkdownMessage(c.CommentSilence(req.ctx, sender.String(), args[0],
+						strings.TrimSpace(words+"\n"+lines)))
+				}),
+			},
 			"del": {
 				Summary: "Delete a silence by ID.",
-				MessageHandler: func(_ mid.UserID, _ string, args ...string) *bot.Message {
-					return bot.NewMarkdownMessage(c.DelSilence(context.Background(), args))
-				},
+				MessageHandler: c.write(req, "delete silences", func(sender mid.UserID, _ string, args ...string) *bot.Message {
+					return bot.NewMarkdownMessage(c.DelSilence(req.ctx, sender.String(), args))
+				}),
 			},
 		},
 	}
 }
 
+// splitThreadArgs splits the arguments of a command given in the thread of a notification
+// into matchers and a comment. Leading words that are neither matchers nor fingerprints are part of the comment,
+// so that `silence add 2h Maintenance` silences the alert group of the thread.
+func splitThreadArgs(args []string) (matcherStr, commentStr string) {
+	i := slices.IndexFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, "\n") || isMatcherArg(arg)
+	})
+	if i < 0 {
+		i = len(args)
+	}
+
+	matchers, comments := splitArgs(args[i:])
+
+	return matchers, strings.TrimSpace(strings.Join(args[:i], " ") + "\n" + comments)
+}
+
+// isMatcherArg returns true if an argument is (part of) a label matcher or an alert fingerprint.
+func isMatcherArg(arg string) bool {
+	if strings.ContainsAny(arg, `{"=~!}`) {
+		return true
+	}
+
+	return len(arg) == 16 && fingerprintRegexp.MatchString(arg) //nolint:mnd // length of a fingerprint
+}
+
 func splitArgs(args []string) (matcherStr, commentStr string) {
 	var matchers, comments []string
 
@@ -193,12 +576,26 @@ func splitArgs(args []string) (matcherStr, commentStr string) {
 
 // Run the client in a blocking thread.
 func (c *Client) Run() error {
-	err := c.joinRooms(context.Background(), c.Matrix.Config.AllowedRooms)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer c.closeCrypto()
+
+	err := c.joinRooms(ctx, c.Matrix.Config.AllowedRooms)
 	if err != nil {
 		return err
 	}
 
-	err = c.Matrix.Run(context.Background())
+	if c.auditRoom != "" {
+		if err := c.joinRooms(ctx, []mid.RoomID{mid.RoomID(c.auditRoom)}); err != nil {
+			return err
+		}
+	}
+
+	if len(c.maintenance) > 0 {
+		go c.runMaintenance(ctx)
+	}
+
+	err = c.sync(ctx)
 	if err != nil {
 		return fmt.Errorf("matrix error: %w", err)
 	}
@@ -207,9 +604,15 @@ func (c *Client) Run() error {
 }
 
 // joinRooms joins a list of room IDs or aliases.
+// Aliases in the list are replaced by their room IDs.
 func (c *Client) joinRooms(ctx context.Context, roomList []mid.RoomID) error {
 	for i, r := range roomList {
-		id, err := c.Matrix.NewRoom(r).Join(ctx)
+		roomID, err := c.ResolveRoom(ctx, string(r))
+		if err != nil {
+			return fmt.Errorf("cannot join room %q: %w", r, err)
+		}
+
+		id, err := c.Matrix.NewRoom(roomID).Join(ctx)
 		if err != nil {
 			return fmt.Errorf("cannot join room %q: %w", r, err)
 		}
@@ -220,9 +623,9 @@ func (c *Client) joinRooms(ctx context.Context, roomList []mid.RoomID) error {
 	return nil
 }
 
-// Alerts returns all or non-silenced alerts.
-func (c *Client) Alerts(ctx context.Context, silenced bool, showLabels bool) *bot.Message {
-	alerts, err := c.Alertmanager.GetAlerts(ctx, silenced)
+// Alerts returns the alerts matching the filter.
+func (c *Client) Alerts(ctx context.Context, filter *alertmanager.AlertFilter, showLabels bool) *bot.Message {
+	alerts, err := c.backend(ctx).FilterAlerts(ctx, filter)
 	if err != nil {
 		return bot.NewTextMessage(err.Error())
 	}
@@ -234,9 +637,23 @@ func (c *Client) Alerts(ctx context.Context, silenced bool, showLabels bool) *bo
 	return bot.NewHTMLMessage(c.Formatter.FormatAlerts(alerts, showLabels))
 }
 
+// AlertGroups returns the alert groups containing alerts matching the filter.
+func (c *Client) AlertGroups(ctx context.Context, filter *alertmanager.AlertFilter) *bot.Message {
+	groups, err := c.backend(ctx).GetAlertGroups(ctx, filter)
+	if err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	if len(groups) == 0 {
+		return bot.NewTextMessage("No alerts")
+	}
+
+	return bot.NewHTMLMessage(c.Formatter.FormatAlertGroups(groups, false, groupMaxAlerts))
+}
+
 // Silences returns a Markdown formatted NewMessage containing silences with the specified state.
 func (c *Client) Silences(ctx context.Context, state string) string {
-	silences, err := c.Alertmanager.GetSilences(ctx)
+	silences, err := c.backend(ctx).GetSilences(ctx)
 	if err != nil {
 		return fmt.Sprintf("Alertmanager error: %s", err)
 	}
@@ -252,67 +669,94 @@ func (c *Client) Silences(ctx context.Context, state string) string {
 
 // NewSilence creates a new silence and returns the ID.
 func (c *Client) NewSilence(ctx context.Context, author, durationStr, matchers, comment string) string {
-	duration, err := parseDuration(durationStr)
+	silence, err := c.newSilence(ctx, author, time.Time{}, durationStr, matchers, comment)
 	if err != nil {
 		return err.Error()
 	}
 
+	return c.createSilence(ctx, silence)
+}
+
+// newSilence returns a new silence for the given duration and matchers or alert fingerprint.
+// The silence starts at the given time, or now if it is zero.
+func (c *Client) newSilence(ctx context.Context, author string, startsAt time.Time,
+	durationStr, matchers, comment string,
+) (alertmanager.Silence, error) {
+	duration, err := parseDuration(durationStr)
+	if err != nil {
+		return alertmanager.Silence{}, err
+	}
+
+	if startsAt.IsZero() {
+		startsAt = time.Now()
+	}
+
 	silence := alertmanager.Silence{
 		GettableSilence: &models.GettableSilence{
 			Silence: models.Silence{
-				Matchers:  make(models.Matchers, 0, len(matchers)),
-				StartsAt:  util.PtrTo(strfmt.DateTime(time.Now())),
-				EndsAt:    util.PtrTo(strfmt.DateTime(time.Now().Add(duration))),
+				StartsAt:  util.PtrTo(strfmt.DateTime(startsAt)),
+				EndsAt:    util.PtrTo(strfmt.DateTime(startsAt.Add(duration))),
 				CreatedBy: &author,
 				Comment:   util.PtrTo(cmp.Or(comment, "Created from Matrix")),
 			},
 		},
 	}
 
-	// Check if an ID is given instead of matchers
-	if !strings.ContainsAny(matchers, `{"=~!}`) {
-		res := c.addSilenceForFingerprint(ctx, &silence.Silence, matchers)
-		if res != "" {
-			return res
-		}
-	} else {
-		ms, parseErr := labels.ParseMatchers(matchers)
-		if parseErr != nil {
-			return fmt.Sprintf("Invalid matchers: %s", parseErr)
-		}
-
-		silence.SetMatchers(ms)
+	ms, err := c.silenceMatchers(ctx, matchers)
+	if err != nil {
+		return alertmanager.Silence{}, err
 	}
 
-	id, err := c.Alertmanager.CreateSilence(ctx, silence)
+	silence.SetMatchers(ms)
+
+	return silence, nil
+}
+
+// createSilence creates a silence and returns a message containing the ID.
+func (c *Client) createSilence(ctx context.Context, silence alertmanager.Silence) string {
+	id, err := c.backend(ctx).CreateSilence(ctx, silence)
+
+	c.audit(ctx, &store.AuditEntry{
+		UserID:    mid.UserID(silence.CreatedBy()),
+		Action:    "silence.create",
+		SilenceID: id,
+		Matchers:  silence.Matchers().String(),
+		Error:     auditError(err),
+	})
+
 	if err != nil {
 		return fmt.Sprintf("Error creating silence: %s", err)
 	}
 
+	if startsAt := silence.StartsAt(); startsAt.After(time.Now()) {
+		return fmt.Sprintf("Silence created with ID *%s*, starting at %s", id, startsAt.Format(silenceTimeFormat))
+	}
+
 	return fmt.Sprintf("Silence created with ID *%s*", id)
 }
 
-func (c *Client) addSilenceForFingerprint(ctx context.Context, silence *models.Silence, fingerprint string) string {
-	alert, err := c.Alertmanager.GetAlert(ctx, fingerprint)
-	if err != nil {
-		return fmt.Sprintf("Error: %s", err)
+// silenceMatchers parses matchers for a silence.
+// If an alert fingerprint is given instead, the matchers match all labels of that alert.
+func (c *Client) silenceMatchers(ctx context.Context, matchers string) (labels.Matchers, error) {
+	if strings.ContainsAny(matchers, `{"=~!}`) {
+		ms, err := labels.ParseMatchers(matchers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matchers: %w", err)
+		}
+
+		return ms, nil
 	}
 
-	silence.Matchers = make(models.Matchers, 0, len(alert.Labels))
-	for name, value := range alert.Labels {
-		silence.Matchers = append(silence.Matchers, &models.Matcher{
-			IsEqual: util.PtrTo(true),
-			IsRegex: util.PtrTo(false),
-			Name:    util.PtrTo(name),
-			Value:   util.PtrTo(value),
-		})
+	alert, err := c.backend(ctx).GetAlert(ctx, matchers)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent wrapper
 	}
 
-	return ""
+	return alertmanager.LabelMatchers(alert.Labels), nil
 }
 
 // DelSilence deletes silences.
-func (c *Client) DelSilence(ctx context.Context, ids []string) string {
+func (c *Client) DelSilence(ctx context.Context, author string, ids []string) string {
 	if len(ids) == 0 {
 		return "No silence IDs provided"
 	}
@@ -320,7 +764,15 @@ func (c *Client) DelSilence(ctx context.Context, ids []string) string {
 	var errs []string
 
 	for _, id := range ids {
-		err := c.Alertmanager.DeleteSilence(ctx, id)
+		err := c.backend(ctx).DeleteSilence(ctx, id)
+
+		c.audit(ctx, &store.AuditEntry{
+			UserID:    mid.UserID(author),
+			Action:    "silence.delete",
+			SilenceID: id,
+			Error:     auditError(err),
+		})
+
 		if err != nil {
 			errs = append(errs,
 				fmt.Sprintf("Error deleting %s: %s", id, err))
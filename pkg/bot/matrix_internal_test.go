@@ -0,0 +1,45 @@
+package bot
+
+import "testing"
+
+func TestSplitThreadArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		matchers string
+		comment  string
+	}{
+		{name: "empty"},
+		{name: "comment", args: []string{"Maintenance"}, comment: "Maintenance"},
+		{name: "words", args: []string{"Database", "maintenance"}, comment: "Database maintenance"},
+		{name: "matchers", args: []string{`job="db"`}, matchers: `job="db"`},
+		{name: "fingerprint", args: []string{"04e45af092081699"}, matchers: "04e45af092081699"},
+		{
+			name:     "comment before matchers",
+			args:     []string{"Maintenance", `job="db"`},
+			matchers: `job="db"`,
+			comment:  "Maintenance",
+		},
+		{
+			name:     "comment lines",
+			args:     []string{"Maintenance", `job="db"`, "\nUpgrade", "\nticket 123"},
+			matchers: `job="db"`,
+			comment:  "Maintenance\nUpgrade\nticket 123",
+		},
+		{name: "comment on new line", args: []string{"\nMaintenance"}, comment: "Maintenance"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			matchers, comment := splitThreadArgs(test.args)
+			if matchers != test.matchers || comment != test.comment {
+				t.Errorf("splitThreadArgs(%q) = %q, %q, want %q, %q",
+					test.args, matchers, comment, test.matchers, test.comment)
+			}
+		})
+	}
+}
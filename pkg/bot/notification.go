@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// notificationKey returns the key identifying a group of alerts.
+// This is the group key from the webhook message if set,
+// or the sorted fingerprints of the alerts otherwise.
+func notificationKey(groupKey string, alerts []*alertmanager.Alert) string {
+	if groupKey != "" {
+		return groupKey
+	}
+
+	n := &store.Notification{Alerts: alerts}
+	fingerprints := n.Fingerprints()
+	slices.Sort(fingerprints)
+
+	return strings.Join(fingerprints, ",")
+}
+
+// resolved returns true if all alerts are resolved.
+func resolved(alerts []*alertmanager.Alert) bool {
+	for _, a := range alerts {
+		if !a.Resolved() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gitlab.com/slxh/matrix/bot"
+	mevent "maunium.net/go/mautrix/event"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// DefaultEditMaxAge is the default maximum age of messages that are edited.
+const DefaultEditMaxAge = 24 * time.Hour
+
+// Notify sends a notification for the alerts in a message to a room.
+// The alerts may be a subset of the alerts in the message.
+//
+// When editing is enabled, the message of the previous notification for the same alert group is edited.
+// When threads are enabled, the first notification for an alert group is the root of a thread,
+// and following notifications for the group are sent in that thread.
+// A new message is sent if the previous notification is older than the maximum edit age or was redacted,
+// and for the first notification after all alerts in the group were resolved.
+// The last notification for an alert group is stored, so that it can be acknowledged using reactions.
+// The Alertmanager backend selected by the context (see [WithAlertmanager]) is stored with the notification,
+// so that silences for its alerts are created in that backend.
+func (c *Client) Notify(ctx context.Context, roomID mid.RoomID, msg *alertmanager.Message,
+	alerts []*alertmanager.Alert, showLabels bool,
+) error {
+	plain, html := c.format(ctx, alerts, showLabels)
+
+	key := notificationKey(msg.GroupKey(), alerts)
+	if key == "" {
+		_, err := c.Matrix.NewRoom(roomID).SendHTML(ctx, plain, html)
+
+		return err //nolint:wrapcheck // transparent wrapper
+	}
+
+	if err := c.store.PruneNotifications(ctx, time.Now().Add(-c.editMaxAge)); err != nil {
+		log.Printf("Error pruning notifications: %s", err)
+	}
+
+	n, err := c.store.Notification(ctx, roomID, key)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Printf("Error loading notification: %s", err)
+	}
+
+	ok := err == nil && (c.editMessages || c.threads)
+	if ok {
+		if err := c.update(ctx, n, plain, html); err != nil {
+			log.Printf("Error updating message %s, sending new message: %s", n.EventID, err)
+
+			ok = false
+		}
+	}
+
+	if !ok {
+		eventID, err := c.Matrix.NewRoom(roomID).SendHTML(ctx, plain, html)
+		if err != nil {
+			return err //nolint:wrapcheck // transparent wrapper
+		}
+
+		n = &store.Notification{RoomID: roomID, EventID: eventID, Key: key, Labels: msg.GroupLabels(), SentAt: time.Now()}
+	}
+
+	n.Alerts = alerts
+	n.ShowLabels = showLabels
+	n.Alertmanager = alertmanagerName(ctx)
+
+	c.deleteAcks(ctx, alerts)
+
+	if resolved(alerts) {
+		err = c.store.DeleteNotification(ctx, roomID, key)
+	} else {
+		err = c.store.SetNotification(ctx, n)
+	}
+
+	// The notification was sent, so only log the error to avoid duplicate notifications by retries
+	if err != nil {
+		log.Printf("Error storing notification: %s", err)
+	}
+
+	return nil
+}
+
+// format formats alerts and their acknowledgements as plain text and HTML.
+func (c *Client) format(ctx context.Context, alerts []*alertmanager.Alert, showLabels bool) (plain, html string) {
+	plain, html = c.Formatter.FormatAlerts(alerts, showLabels)
+	ackPlain, ackHTML := c.Formatter.FormatAcks(c.acks(ctx, alerts))
+
+	return plain + ackPlain, html + ackHTML
+}
+
+// update updates a previous notification by sending a message in its thread and/or editing its message.
+func (c *Client) update(ctx context.Context, n *store.Notification, plain, html string) error {
+	if !c.threads {
+		return c.edit(ctx, n, plain, html)
+	}
+
+	if err := c.sendThread(ctx, n, plain, html); err != nil {
+		return err
+	}
+
+	if c.editMessages {
+		// The update is already sent in the thread, so do not fall back to a new message
+		if err := c.edit(ctx, n, plain, html); err != nil {
+			log.Printf("Error editing message %s: %s", n.EventID, err)
+		}
+	}
+
+	return nil
+}
+
+// sendThread sends a message in the thread of a notification.
+func (c *Client) sendThread(ctx context.Context, n *store.Notification, plain, html string) error {
+	content := &mevent.MessageEventContent{
+		MsgType:       c.Matrix.Config.MessageType,
+		Body:          plain,
+		Format:        mevent.FormatHTML,
+		FormattedBody: html,
+		RelatesTo:     (&mevent.RelatesTo{}).SetThread(n.EventID, n.EventID),
+	}
+
+	log.Printf("Sending message in thread %s in %s", n.EventID, n.RoomID)
+
+	_, err := c.Matrix.Client.SendMessageEvent(ctx, n.RoomID, mevent.EventMessage, content)
+	if err != nil {
+		return fmt.Errorf("error sending thread message: %w", err)
+	}
+
+	return nil
+}
+
+// edit replaces the content of the message of a notification.
+func (c *Client) edit(ctx context.Context, n *store.Notification, plain, html string) error {
+	content := &mevent.MessageEventContent{
+		MsgType:       c.Matrix.Config.MessageType,
+		Body:          plain,
+		Format:        mevent.FormatHTML,
+		FormattedBody: html,
+	}
+	content.SetEdit(n.EventID)
+
+	log.Printf("Editing message %s in %s", n.EventID, n.RoomID)
+
+	_, err := c.Matrix.Client.SendMessageEvent(ctx, n.RoomID, mevent.EventMessage, content)
+	if err != nil {
+		return fmt.Errorf("error sending edit: %w", err)
+	}
+
+	return nil
+}
+
+// handleRedaction removes notifications of which the message was redacted,
+// so that no edits are sent for them.
+func (c *Client) handleRedaction(ctx context.Context, e *bot.Event) {
+	redacts := e.Redacts
+	if content := e.Content.AsRedaction(); content.Redacts != "" {
+		redacts = content.Redacts
+	}
+
+	if err := c.store.DeleteNotificationByEvent(ctx, e.RoomID, redacts); err != nil {
+		log.Printf("Error deleting notification: %s", err)
+	}
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"gitlab.com/slxh/matrix/bot"
+	mevent "maunium.net/go/mautrix/event"
+	mid "maunium.net/go/mautrix/id"
+)
+
+// Permission contains the requirements for running bot commands.
+// A user has the permission if any of the requirements is met,
+// and all users have the permission if there are no requirements.
+type Permission struct {
+	PowerLevel *int         // Minimum power level of the user in the room (optional).
+	Users      []mid.UserID // Users that have the permission.
+	Servers    []string     // Homeservers of users that have the permission.
+}
+
+// restricted returns true if the permission has any requirements.
+func (p *Permission) restricted() bool {
+	return p.PowerLevel != nil || len(p.Users) > 0 || len(p.Servers) > 0
+}
+
+// messageHandler is the message handler of a bot command.
+type messageHandler = func(sender mid.UserID, cmd string, args ...string) *bot.Message
+
+// permitted returns true if a user has a permission in a room.
+func (c *Client) permitted(ctx context.Context, p *Permission, roomID mid.RoomID, userID mid.UserID) bool {
+	switch {
+	case !p.restricted(), slices.Contains(p.Users, userID), slices.Contains(p.Servers, userID.Homeserver()):
+		return true
+	case p.PowerLevel == nil:
+		return false
+	}
+
+	var levels mevent.PowerLevelsEventContent
+
+	err := c.Matrix.Client.StateEvent(ctx, roomID, mevent.StatePowerLevels, "", &levels)
+	if err != nil {
+		log.Printf("Error retrieving power levels of %s: %s", roomID, err)
+
+		return false
+	}
+
+	return levels.GetUserLevel(userID) >= *p.PowerLevel
+}
+
+// checkWrite returns a denial if the sender of a request does not have the write permission,
+// or nil otherwise.
+func (c *Client) checkWrite(req *request, action string) *bot.Message {
+	if c.permitted(req.ctx, &c.writePermission, req.roomID, req.sender) {
+		return nil
+	}
+
+	return c.deny(req.sender, req.roomID, auditSourceFrom(req.ctx).command, action)
+}
+
+// write returns a message handler that only calls the given handler
+// if the sender has the write permission.
+func (c *Client) write(req *request, action string, handler messageHandler) messageHandler {
+	return func(sender mid.UserID, cmd string, args ...string) *bot.Message {
+		if denied := c.checkWrite(req, action); denied != nil {
+			return denied
+		}
+
+		return handler(sender, cmd, args...)
+	}
+}
+
+// deny logs and returns a denial of an action.
+// The raw command is logged, so that denied attempts can be traced.
+func (c *Client) deny(sender mid.UserID, roomID mid.RoomID, command, action string) *bot.Message {
+	log.Printf("Denied %q to %s in %s: %q", action, sender, roomID, command)
+
+	return bot.NewTextMessage(fmt.Sprintf("Sorry, you are not allowed to %s.", action))
+}
@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"gitlab.com/slxh/matrix/bot"
+	mevent "maunium.net/go/mautrix/event"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// confirmationTTL is the duration that silences wait for confirmation.
+const confirmationTTL = 15 * time.Minute
+
+// Confirmation of silences.
+const (
+	confirmText     = "yes"
+	confirmReaction = "✅"
+)
+
+// pendingSilence contains silences waiting for confirmation.
+type pendingSilence struct {
+	silences []alertmanager.Silence
+	req      *request
+	expires  time.Time
+}
+
+// confirmationID identifies a message asking for confirmation.
+type confirmationID struct {
+	roomID  mid.RoomID
+	eventID mid.EventID
+}
+
+// confirmationStore contains the silences waiting for confirmation.
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[confirmationID]*pendingSilence
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{pending: make(map[confirmationID]*pendingSilence)}
+}
+
+// add adds a silence waiting for confirmation in response to the given message.
+func (s *confirmationStore) add(roomID mid.RoomID, eventID mid.EventID, p *pendingSilence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	for id, p := range s.pending {
+		if now.After(p.expires) {
+			delete(s.pending, id)
+		}
+	}
+
+	s.pending[confirmationID{roomID, eventID}] = p
+}
+
+// take removes and returns the silence waiting for confirmation in response to the given message.
+// Only the user that requested the silence can confirm it.
+func (s *confirmationStore) take(roomID mid.RoomID, eventID mid.EventID, sender mid.UserID) (*pendingSilence, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := confirmationID{roomID, eventID}
+
+	p, ok := s.pending[id]
+	if !ok || p.req.sender != sender || time.Now().After(p.expires) {
+		return nil, false
+	}
+
+	delete(s.pending, id)
+
+	return p, true
+}
+
+// matchingAlerts returns the active, silenced and inhibited alerts matching the given matchers.
+func (c *Client) matchingAlerts(ctx context.Context, matchers labels.Matchers) ([]*alertmanager.Alert, error) {
+	alerts, err := c.backend(ctx).FilterAlerts(ctx, &alertmanager.AlertFilter{Silenced: true, Inhibited: true})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent wrapper
+	}
+
+	return slices.DeleteFunc(alerts, func(a *alertmanager.Alert) bool {
+		return !a.Matches(matchers)
+	}), nil
+}
+
+// previewMessage returns a message with a Markdown header followed by a list of alerts.
+func (c *Client) previewMessage(header string, alerts []*alertmanager.Alert) *bot.Message {
+	msg := bot.NewMarkdownMessage(header)
+	plain, html := c.Formatter.FormatAlerts(alerts, false)
+
+	return bot.NewHTMLMessage(msg.Body+"\n\n"+plain, msg.FormattedBody+html)
+}
+
+// PreviewSilence returns a message listing the alerts that would be silenced by the given matchers.
+func (c *Client) PreviewSilence(ctx context.Context, matchers string) *bot.Message {
+	ms, err := c.silenceMatchers(ctx, matchers)
+	if err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	alerts, err := c.matchingAlerts(ctx, ms)
+	if err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	if len(alerts) == 0 {
+		return bot.NewMarkdownMessage(fmt.Sprintf("No alerts match `%s`", ms))
+	}
+
+	return c.previewMessage(fmt.Sprintf("%d alerts match `%s`:", len(alerts), ms), alerts)
+}
+
+// addSilence creates one or more silences after showing the alerts they match.
+// If more alerts than the confirmation threshold would be silenced,
+// the silences are only created after they are confirmed by the user.
+func (c *Client) addSilence(req *request, silences ...alertmanager.Silence) *bot.Message {
+	alerts, err := c.silencedAlerts(req.ctx, silences)
+	if err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	if c.confirmThreshold > 0 && len(alerts) > c.confirmThreshold {
+		req.sent = func(eventID mid.EventID) {
+			c.confirmations.add(req.roomID, eventID, &pendingSilence{
+				silences: silences,
+				req:      req,
+				expires:  time.Now().Add(confirmationTTL),
+			})
+		}
+
+		what, it := "This silence", "it"
+		if len(silences) > 1 {
+			what, it = fmt.Sprintf("These %d silences", len(silences)), "them"
+		}
+
+		return c.previewMessage(fmt.Sprintf(
+			"%s would match %d alerts. Reply `%s` or react with %s within %s to create %s:",
+			what, len(alerts), confirmText, confirmReaction, confirmationTTL, it), alerts)
+	}
+
+	result := c.createSilences(req.ctx, silences)
+	if len(alerts) == 0 {
+		return bot.NewMarkdownMessage(result)
+	}
+
+	return c.previewMessage(fmt.Sprintf("%s\n\nMatching %d alerts:", result, len(alerts)), alerts)
+}
+
+// silencedAlerts returns the alerts matching any of the given silences.
+func (c *Client) silencedAlerts(ctx context.Context, silences []alertmanager.Silence) ([]*alertmanager.Alert, error) {
+	var alerts []*alertmanager.Alert
+
+	for _, silence := range silences {
+		matching, err := c.matchingAlerts(ctx, silence.Matchers())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range matching {
+			if !slices.ContainsFunc(alerts, func(b *alertmanager.Alert) bool { return b.Fingerprint == a.Fingerprint }) {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+
+	return alerts, nil
+}
+
+// createSilences creates the given silences and returns the results.
+func (c *Client) createSilences(ctx context.Context, silences []alertmanager.Silence) string {
+	results := make([]string, len(silences))
+	for i, silence := range silences {
+		results[i] = c.createSilence(ctx, silence)
+	}
+
+	return strings.Join(results, "\n\n")
+}
+
+// appendMarkdown returns a message with the given Markdown appended to it.
+func appendMarkdown(msg *bot.Message, markdown string) *bot.Message {
+	if msg.Format != mevent.FormatHTML {
+		return bot.NewMarkdownMessage(msg.Body + "\n\n" + markdown)
+	}
+
+	extra := bot.NewMarkdownMessage(markdown)
+
+	return bot.NewHTMLMessage(msg.Body+"\n\n"+extra.Body, msg.FormattedBody+extra.FormattedBody)
+}
+
+// handleConfirmationReply creates a pending silence if the message confirms it.
+// It returns true if the message was a confirmation.
+func (c *Client) handleConfirmationReply(ctx context.Context, e *bot.Event, content *mevent.MessageEventContent) bool {
+	replyTo := content.RelatesTo.GetReplyTo()
+	if replyTo == "" || !strings.EqualFold(strings.TrimSpace(mevent.TrimReplyFallbackText(content.Body)), confirmText) {
+		return false
+	}
+
+	p, ok := c.confirmations.take(e.RoomID, replyTo, e.Sender)
+	if !ok {
+		return false
+	}
+
+	c.confirm(c.newRequest(ctx, e, content), p)
+
+	return true
+}
+
+// handleConfirmationReaction creates a pending silence if the reaction confirms it.
+// It returns true if the reaction was a confirmation.
+func (c *Client) handleConfirmationReaction(ctx context.Context, e *bot.Event,
+	content *mevent.ReactionEventContent,
+) bool {
+	if strings.TrimSuffix(content.RelatesTo.Key, variationSelector) != confirmReaction {
+		return false
+	}
+
+	p, ok := c.confirmations.take(e.RoomID, content.RelatesTo.EventID, e.Sender)
+	if !ok {
+		return false
+	}
+
+	req := *p.req
+	req.ctx = ctx
+
+	c.confirm(&req, p)
+
+	return true
+}
+
+// confirm creates a confirmed silence and sends the result.
+func (c *Client) confirm(req *request, p *pendingSilence) {
+	// Use the Alertmanager backend that the silence was previewed for, and audit the original command
+	src := auditSourceFrom(p.req.ctx)
+	ctx := withAuditSource(WithAlertmanager(req.ctx, alertmanagerName(p.req.ctx)), src.roomID, src.command)
+
+	if _, err := c.reply(req, bot.NewMarkdownMessage(c.createSilences(ctx, p.silences))); err != nil {
+		log.Printf("Error sending response: %s", err)
+	}
+}
@@ -0,0 +1,201 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"gitlab.com/slxh/matrix/bot"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// DefaultAckSilenceDuration is the default duration of silences created by reactions.
+const DefaultAckSilenceDuration = time.Hour
+
+// ReactionAction represents the action taken when a user reacts to a notification.
+type ReactionAction string
+
+// Reaction actions.
+const (
+	ReactionAck     ReactionAction = "ack"     // Acknowledge the alerts.
+	ReactionSilence ReactionAction = "silence" // Acknowledge and silence the alerts.
+)
+
+// Reaction contains the action for a reaction to a notification.
+type Reaction struct {
+	Action   ReactionAction // Action to take.
+	Duration time.Duration  // Duration of the silence for [ReactionSilence] (optional).
+}
+
+// variationSelector is appended to some emoji by clients, and ignored when matching reactions.
+const variationSelector = "\ufe0f"
+
+// reaction returns the configured reaction for a reaction key.
+func (c *Client) reaction(key string) (Reaction, bool) {
+	key = strings.TrimSuffix(key, variationSelector)
+
+	for k, r := range c.reactions {
+		if strings.TrimSuffix(k, variationSelector) == key {
+			return r, true
+		}
+	}
+
+	return Reaction{}, false
+}
+
+// handleReaction acknowledges the alerts of a notification that a user reacted to.
+func (c *Client) handleReaction(ctx context.Context, e *bot.Event) {
+	if !c.Matrix.NewRoom(e.RoomID).Allowed() || e.Sender == c.Matrix.Client.UserID {
+		return
+	}
+
+	content := e.Content.AsReaction()
+	if c.handleConfirmationReaction(ctx, e, content) {
+		return
+	}
+
+	reaction, ok := c.reaction(content.RelatesTo.Key)
+	if !ok {
+		return
+	}
+
+	n, err := c.store.NotificationByEvent(ctx, e.RoomID, content.RelatesTo.EventID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("Error loading notification: %s", err)
+		}
+
+		return
+	}
+
+	if !c.permitted(ctx, &c.writePermission, e.RoomID, e.Sender) {
+		denied := c.deny(e.Sender, e.RoomID, "reaction "+content.RelatesTo.Key, "acknowledge alerts")
+		if _, err := c.reply(&request{ctx: ctx, event: e, roomID: e.RoomID}, denied); err != nil {
+			log.Printf("Error sending response: %s", err)
+		}
+
+		return
+	}
+
+	log.Printf("Alerts in %s acknowledged by %s with %q", n.EventID, e.Sender, content.RelatesTo.Key)
+
+	ctx = withAuditSource(c.selectBackend(ctx, e.RoomID, n), e.RoomID, "reaction "+content.RelatesTo.Key)
+
+	for _, a := range n.Alerts {
+		if a.Resolved() || a.Fingerprint == "" {
+			continue
+		}
+
+		ack := &store.Ack{
+			Fingerprint: a.Fingerprint,
+			UserID:      e.Sender,
+			RoomID:      e.RoomID,
+			EventID:     e.ID,
+			Time:        time.Now(),
+		}
+
+		if reaction.Action == ReactionSilence {
+			ack.SilenceID, err = c.silenceAlert(ctx, e.Sender, a, cmp.Or(reaction.Duration, DefaultAckSilenceDuration))
+			if err != nil {
+				log.Printf("Error silencing alert %s: %s", a.Fingerprint, err)
+			}
+		}
+
+		if err := c.store.SetAck(ctx, ack); err != nil {
+			log.Printf("Error storing acknowledgement: %s", err)
+		}
+	}
+
+	c.audit(ctx, &store.AuditEntry{
+		UserID:  e.Sender,
+		RoomID:  e.RoomID,
+		Action:  "alert.ack",
+		Details: strings.Join(n.Fingerprints(), ","),
+	})
+
+	plain, html := c.format(ctx, n.Alerts, n.ShowLabels)
+	if err := c.edit(ctx, n, plain, html); err != nil {
+		log.Printf("Error editing message %s: %s", n.EventID, err)
+	}
+}
+
+// silenceAlert creates a silence matching all labels of an alert, and returns the ID.
+func (c *Client) silenceAlert(ctx context.Context, sender mid.UserID, alert *alertmanager.Alert,
+	duration time.Duration,
+) (string, error) {
+	silence := alertmanager.Silence{
+		GettableSilence: &models.GettableSilence{
+			Silence: models.Silence{
+				StartsAt:  util.PtrTo(strfmt.DateTime(time.Now())),
+				EndsAt:    util.PtrTo(strfmt.DateTime(time.Now().Add(duration))),
+				CreatedBy: util.PtrTo(sender.String()),
+				Comment:   util.PtrTo(fmt.Sprintf("Acknowledged by %s from Matrix", sender)),
+			},
+		},
+	}
+
+	silence.SetMatchers(alertmanager.LabelMatchers(alert.Labels))
+
+	id, err := c.backend(ctx).CreateSilence(ctx, silence)
+
+	c.audit(ctx, &store.AuditEntry{
+		UserID:    sender,
+		Action:    "silence.create",
+		SilenceID: id,
+		Matchers:  silence.Matchers().String(),
+		Error:     auditError(err),
+	})
+
+	if err != nil {
+		return "", err //nolint:wrapcheck // transparent wrapper
+	}
+
+	return id, nil
+}
+
+// acks returns the acknowledgements of the unresolved alerts.
+func (c *Client) acks(ctx context.Context, alerts []*alertmanager.Alert) []*store.Ack {
+	var acks []*store.Ack
+
+	for _, a := range alerts {
+		if a.Resolved() || a.Fingerprint == "" {
+			continue
+		}
+
+		ack, err := c.store.Ack(ctx, a.Fingerprint)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				log.Printf("Error loading acknowledgement: %s", err)
+			}
+
+			continue
+		}
+
+		acks = append(acks, ack)
+	}
+
+	return acks
+}
+
+// deleteAcks removes the acknowledgements of resolved alerts.
+func (c *Client) deleteAcks(ctx context.Context, alerts []*alertmanager.Alert) {
+	for _, a := range alerts {
+		if !a.Resolved() || a.Fingerprint == "" {
+			continue
+		}
+
+		if err := c.store.DeleteAck(ctx, a.Fingerprint); err != nil {
+			log.Printf("Error deleting acknowledgement: %s", err)
+		}
+	}
+}
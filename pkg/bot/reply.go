@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/slxh/matrix/bot"
+	mevent "maunium.net/go/mautrix/event"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Scopes of silences created by replying to a notification.
+const (
+	scopeGroup  = "group"  // silence the alert group
+	scopeCommon = "common" // silence the labels common to all alerts
+)
+
+// Errors returned when silencing alerts by replying to a message.
+var (
+	errNoAlertGroup   = errors.New("no alert group is known for this message")
+	errNoCommonLabels = errors.New("the alerts have no labels in common")
+	errNoReplyTargets = errors.New("no alerts found to silence")
+	errEncrypted      = errors.New("the message is encrypted, and encryption is not enabled")
+)
+
+// fingerprintRegexp matches alert fingerprints in messages.
+var fingerprintRegexp = regexp.MustCompile(`\b[0-9a-f]{16}\b`)
+
+// silenceReply silences the alerts of the notification that a request refers to.
+// The arguments are the duration, an optional scope, and an optional comment.
+// The silences are previewed and confirmed like those created by the `silence add` command.
+func (c *Client) silenceReply(req *request, sender mid.UserID, args []string) *bot.Message {
+	duration, scope, args := args[0], "", args[1:]
+
+	if len(args) > 0 && (args[0] == scopeGroup || args[0] == scopeCommon) {
+		scope, args = args[0], args[1:]
+	}
+
+	if _, err := parseDuration(duration); err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	words, lines := splitArgs(args)
+	comment := strings.TrimSpace(words + "\n" + lines)
+
+	targets, err := c.silenceReplyTargets(req, scope)
+	if err != nil {
+		return bot.NewTextMessage(err.Error())
+	}
+
+	silences := make([]alertmanager.Silence, len(targets))
+	for i, target := range targets {
+		silences[i], err = c.newSilence(req.ctx, sender.String(), time.Time{}, duration, target, comment)
+		if err != nil {
+			return bot.NewTextMessage(err.Error())
+		}
+	}
+
+	msg := c.addSilence(req, silences...)
+	if scope == "" && len(targets) > 1 {
+		msg = appendMarkdown(msg, fmt.Sprintf(
+			"Use `silence %s %s` or `silence %s %s` to silence the alert group or common labels instead.",
+			duration, scopeGroup, duration, scopeCommon))
+	}
+
+	return msg
+}
+
+// silenceReplyTargets returns the matchers or fingerprints to silence for a reply with the given scope.
+func (c *Client) silenceReplyTargets(req *request, scope string) ([]string, error) {
+	if scope == "" {
+		targets, err := c.replyTargets(req)
+		if err == nil && len(targets) == 0 {
+			return nil, errNoReplyTargets
+		}
+
+		return targets, err
+	}
+
+	if req.notification == nil {
+		return nil, errNoAlertGroup
+	}
+
+	matchers := req.notification.Matchers()
+	if scope == scopeCommon {
+		matchers = req.notification.CommonMatchers()
+	}
+
+	if len(matchers) == 0 {
+		return nil, errNoCommonLabels
+	}
+
+	return []string{matchers.String()}, nil
+}
+
+// replyTargets returns the matchers or fingerprints for each alert that a request refers to.
+// These are the fingerprints in the message the request replies to if it is not a known notification,
+// or the alerts of the notification that the request refers to otherwise.
+func (c *Client) replyTargets(req *request) ([]string, error) {
+	if req.replyTo != "" && (req.notification == nil || req.replyTo != req.notification.EventID) {
+		fingerprints, err := c.messageFingerprints(req.ctx, req.roomID, req.replyTo)
+		if err != nil || len(fingerprints) > 0 || req.notification == nil {
+			return fingerprints, err
+		}
+	}
+
+	var targets []string
+
+	for _, a := range req.notification.Alerts {
+		if !a.Resolved() {
+			targets = append(targets, alertmanager.LabelMatchers(a.Labels).String())
+		}
+	}
+
+	return targets, nil
+}
+
+// messageFingerprints returns the alert fingerprints in the body of a message.
+// Encrypted messages are decrypted if end-to-end encryption is enabled.
+func (c *Client) messageFingerprints(ctx context.Context, roomID mid.RoomID, eventID mid.EventID) ([]string, error) {
+	evt, err := c.Matrix.Client.GetEvent(ctx, roomID, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving message: %w", err)
+	}
+
+	err = evt.Content.ParseRaw(evt.Type)
+	if err != nil && !errors.Is(err, mevent.ErrContentAlreadyParsed) {
+		return nil, fmt.Errorf("error parsing message: %w", err)
+	}
+
+	// Messages in encrypted rooms are returned as sent, and have to be decrypted
+	if evt.Type == mevent.EventEncrypted {
+		if c.Matrix.Client.Crypto == nil {
+			return nil, errEncrypted
+		}
+
+		evt, err = c.Matrix.Client.Crypto.Decrypt(ctx, evt)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting message: %w", err)
+		}
+	}
+
+	var fingerprints []string
+
+	if content := evt.Content.AsMessage(); content != nil {
+		for _, fp := range fingerprintRegexp.FindAllString(content.Body, -1) {
+			if !slices.Contains(fingerprints, fp) {
+				fingerprints = append(fingerprints, fp)
+			}
+		}
+	}
+
+	return fingerprints, nil
+}
@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mid "maunium.net/go/mautrix/id"
+)
+
+// Errors returned when parsing the start of a scheduled silence.
+var (
+	errInvalidTime = errors.New("invalid time")
+	errMissingFor  = errors.New("expected `for <duration>` after the start time")
+)
+
+// Layouts of absolute times, in the order they are tried.
+var timeLayouts = []string{ //nolint:gochecknoglobals // used as constant
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// Layouts of times of day, in the order they are tried.
+var clockLayouts = []string{"15:04:05", "15:04"} //nolint:gochecknoglobals // used as constant
+
+// parseSchedule parses the start time and duration of a scheduled silence,
+// in the form `<time> for <duration>`, and returns the remaining arguments.
+// See [parseTime] for the supported time formats.
+func parseSchedule(args []string, now time.Time) (startsAt time.Time, duration string, rest []string, err error) {
+	startsAt, rest, err = parseTime(args, now)
+	if err != nil {
+		return time.Time{}, "", nil, err
+	}
+
+	if len(rest) < 2 || rest[0] != "for" { //nolint:mnd // `for` and duration
+		return time.Time{}, "", nil, errMissingFor
+	}
+
+	return startsAt, rest[1], rest[2:], nil
+}
+
+// parseTime parses a time at the start of the arguments in the location of now,
+// and returns the remaining arguments. The supported formats are:
+//
+//   - An absolute time, such as `2026-10-20T22:00`, `2026-10-20 22:00` or `2026-10-20T22:00:00+02:00`.
+//   - A time of day, such as `22:00`, which is the next occurrence of that time.
+//   - A day followed by a time of day, such as `tomorrow 06:00` or `monday 06:00`.
+//     The day is `today`, `tomorrow`, or a weekday, which is the next occurrence of that day and time.
+func parseTime(args []string, now time.Time) (time.Time, []string, error) {
+	if len(args) == 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: no time given", errInvalidTime)
+	}
+
+	// Date and time of day as separate arguments
+	if len(args) > 1 {
+		if t, err := parseAbsoluteTime(args[0]+"T"+args[1], now.Location()); err == nil {
+			return t, args[2:], nil
+		}
+	}
+
+	if t, err := parseAbsoluteTime(args[0], now.Location()); err == nil {
+		return t, args[1:], nil
+	}
+
+	if clock, err := parseClock(args[0]); err == nil {
+		t := atClock(now, clock)
+		if t.Before(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+
+		return t, args[1:], nil
+	}
+
+	if len(args) > 1 {
+		if t, err := parseDay(args[0], args[1], now); err == nil {
+			return t, args[2:], nil
+		}
+	}
+
+	return time.Time{}, nil, fmt.Errorf("%w: %q", errInvalidTime, args[0])
+}
+
+// parseAbsoluteTime parses an absolute time in the given location, unless it contains a time zone.
+func parseAbsoluteTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", errInvalidTime, s)
+}
+
+// parseClock parses a time of day.
+// Only the hour, minute and second of the returned time are relevant.
+func parseClock(s string) (time.Time, error) {
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", errInvalidTime, s)
+}
+
+// parseDay parses a day and time of day relative to now.
+func parseDay(day, clock string, now time.Time) (time.Time, error) {
+	c, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t := atClock(now, c)
+
+	switch day = strings.ToLower(day); day {
+	case "today":
+		return t, nil
+	case "tomorrow":
+		return t.AddDate(0, 0, 1), nil
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if day != strings.ToLower(weekday.String()) {
+			continue
+		}
+
+		t = t.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7) //nolint:mnd // days in a week
+		if t.Before(now) {
+			t = t.AddDate(0, 0, 7) //nolint:mnd // days in a week
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: unknown day %q", errInvalidTime, day)
+}
+
+// atClock returns the time of day of clock on the day of t.
+func atClock(t, clock time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), 0, t.Location())
+}
+
+// location returns the time zone for a user in a room.
+// The time zone of the user takes precedence over that of the room,
+// and the default time zone is used if neither is configured.
+func (c *Client) location(ctx context.Context, userID mid.UserID, roomID mid.RoomID) *time.Location {
+	if loc, ok := c.userLocations[userID]; ok {
+		return loc
+	}
+
+	for room, loc := range c.roomLocations {
+		if id, err := c.ResolveRoom(ctx, room); err == nil && id == roomID {
+			return loc
+		}
+	}
+
+	return c.defaultLocation
+}
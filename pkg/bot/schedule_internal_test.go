@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testLocation is the time zone of the times in the schedule tests.
+var testLocation = time.FixedZone("CEST", 2*60*60)
+
+// testNow is the current time in the schedule tests: Tuesday 2026-10-20 10:30.
+var testNow = time.Date(2026, 10, 20, 10, 30, 0, 0, testLocation)
+
+func testDate(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, testLocation)
+}
+
+func TestParseTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want time.Time
+		rest []string
+		err  bool
+	}{
+		{
+			name: "absolute",
+			args: []string{"2026-10-21T22:00", "for", "2h"},
+			want: testDate(21, 22, 0),
+			rest: []string{"for", "2h"},
+		},
+		{
+			name: "absolute with seconds",
+			args: []string{"2026-10-21T22:00:30"},
+			want: testDate(21, 22, 0).Add(30 * time.Second),
+		},
+		{
+			name: "date and time",
+			args: []string{"2026-10-21", "22:00", "for"},
+			want: testDate(21, 22, 0),
+			rest: []string{"for"},
+		},
+		{name: "date only", args: []string{"2026-10-21", "for"}, want: testDate(21, 0, 0), rest: []string{"for"}},
+		{
+			name: "time zone",
+			args: []string{"2026-10-21T22:00:00Z"},
+			want: time.Date(2026, 10, 21, 22, 0, 0, 0, time.UTC),
+		},
+		{name: "later today", args: []string{"22:00"}, want: testDate(20, 22, 0)},
+		{name: "earlier tomorrow", args: []string{"06:00"}, want: testDate(21, 6, 0)},
+		{name: "now", args: []string{"10:30:00"}, want: testNow},
+		{
+			name: "day and time",
+			args: []string{"tomorrow", "06:00", "for", "1h"},
+			want: testDate(21, 6, 0),
+			rest: []string{"for", "1h"},
+		},
+		{name: "no time", err: true},
+		{name: "invalid", args: []string{"soon"}, err: true},
+		{name: "day without time", args: []string{"tomorrow"}, err: true},
+		{name: "invalid time of day", args: []string{"25:00"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, rest, err := parseTime(test.args, testNow)
+
+			if test.err {
+				if !errors.Is(err, errInvalidTime) {
+					t.Errorf("parseTime(%q) error = %v, want %v", test.args, err, errInvalidTime)
+				}
+
+				return
+			}
+
+			if err != nil || !got.Equal(test.want) || !slices.Equal(rest, test.rest) {
+				t.Errorf("parseTime(%q) = %s, %q, %v, want %s, %q", test.args, got, rest, err, test.want, test.rest)
+			}
+		})
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		day, clock string
+		want       time.Time
+		err        bool
+	}{
+		{day: "today", clock: "22:00", want: testDate(20, 22, 0)},
+		{day: "today", clock: "06:00", want: testDate(20, 6, 0)},
+		{day: "Tomorrow", clock: "06:00", want: testDate(21, 6, 0)},
+		{day: "wednesday", clock: "06:00", want: testDate(21, 6, 0)},
+		{day: "Monday", clock: "06:00", want: testDate(26, 6, 0)},
+		{day: "tuesday", clock: "22:00", want: testDate(20, 22, 0)},
+		{day: "tuesday", clock: "06:00", want: testDate(27, 6, 0)},
+		{day: "sunday", clock: "12:00:30", want: testDate(25, 12, 0).Add(30 * time.Second)},
+		{day: "someday", clock: "06:00", err: true},
+		{day: "monday", clock: "6 o'clock", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.day+" "+test.clock, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseDay(test.day, test.clock, testNow)
+
+			if test.err {
+				if !errors.Is(err, errInvalidTime) {
+					t.Errorf("parseDay(%q, %q) error = %v, want %v", test.day, test.clock, err, errInvalidTime)
+				}
+
+				return
+			}
+
+			if err != nil || !got.Equal(test.want) {
+				t.Errorf("parseDay(%q, %q) = %s, %v, want %s", test.day, test.clock, got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"monday", "06:00", "for", "2h", "alertname=Test"}
+
+	startsAt, duration, rest, err := parseSchedule(args, testNow)
+	if err != nil || !startsAt.Equal(testDate(26, 6, 0)) || duration != "2h" ||
+		!slices.Equal(rest, []string{"alertname=Test"}) {
+		t.Errorf("parseSchedule() = %s, %q, %q, %v", startsAt, duration, rest, err)
+	}
+
+	if _, _, _, err := parseSchedule([]string{"22:00", "2h"}, testNow); !errors.Is(err, errMissingFor) {
+		t.Errorf("parseSchedule() without `for` error = %v, want %v", err, errMissingFor)
+	}
+}
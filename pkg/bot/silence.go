@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// silenceTimeFormat is the format of times in silence messages.
+const silenceTimeFormat = "2006-01-02 15:04:05 MST"
+
+// UpdateSilence modifies an existing silence using the given function, and reposts it with its ID.
+// It returns a message containing the differences between the old and new silence.
+func (c *Client) UpdateSilence(ctx context.Context, author, id string,
+	update func(s *alertmanager.Silence) error,
+) string {
+	silence, err := c.backend(ctx).GetSilence(ctx, id)
+	if err != nil {
+		return err.Error()
+	}
+
+	before := silenceFields(silence)
+
+	if err := update(silence); err != nil {
+		return err.Error()
+	}
+
+	newID, err := c.backend(ctx).CreateSilence(ctx, *silence)
+
+	entry := &store.AuditEntry{
+		UserID:    mid.UserID(author),
+		Action:    "silence.update",
+		SilenceID: id,
+		Matchers:  silence.Matchers().String(),
+		Error:     auditError(err),
+	}
+
+	if newID != "" && newID != id {
+		entry.SilenceID, entry.Details = newID, "replaces "+id
+	}
+
+	c.audit(ctx, entry)
+
+	if err != nil {
+		return fmt.Sprintf("Error updating silence: %s", err)
+	}
+
+	msg := fmt.Sprintf("Silence *%s* updated", id)
+	if newID != id {
+		msg = fmt.Sprintf("Silence *%s* replaced by *%s*", id, newID)
+	}
+
+	return msg + ":\n\n" + silenceDiff(before, silenceFields(silence))
+}
+
+// ExtendSilence extends the end time of a silence by a duration.
+// Silences that have already ended are extended from the current time.
+func (c *Client) ExtendSilence(ctx context.Context, author, id, durationStr string) string {
+	duration, err := parseDuration(durationStr)
+	if err != nil {
+		return err.Error()
+	}
+
+	return c.UpdateSilence(ctx, author, id, func(s *alertmanager.Silence) error {
+		endsAt := s.EndsAt()
+		if now := time.Now(); endsAt.Before(now) {
+			endsAt = now
+		}
+
+		s.GettableSilence.EndsAt = util.PtrTo(strfmt.DateTime(endsAt.Add(duration)))
+
+		return nil
+	})
+}
+
+// EditSilence replaces the matchers of a silence.
+func (c *Client) EditSilence(ctx context.Context, author, id, matchers string) string {
+	return c.UpdateSilence(ctx, author, id, func(s *alertmanager.Silence) error {
+		ms, err := c.silenceMatchers(ctx, matchers)
+		if err != nil {
+			return err
+		}
+
+		s.SetMatchers(ms)
+
+		return nil
+	})
+}
+
+// CommentSilence replaces the comment of a silence.
+func (c *Client) CommentSilence(ctx context.Context, author, id, comment string) string {
+	return c.UpdateSilence(ctx, author, id, func(s *alertmanager.Silence) error {
+		s.GettableSilence.Comment = &comment
+
+		return nil
+	})
+}
+
+// silenceField contains a field of a silence that can be changed from Matrix.
+type silenceField struct {
+	name, value string
+}
+
+// silenceFields returns the fields of a silence that can be changed from Matrix.
+func silenceFields(s *alertmanager.Silence) []silenceField {
+	return []silenceField{
+		{"Ends", s.EndsAt().Format(silenceTimeFormat)},
+		{"Matches", s.Matchers().String()},
+		{"Comment", s.Comment()},
+	}
+}
+
+// silenceDiff returns a Markdown code block containing the differences between silence fields.
+func silenceDiff(before, after []silenceField) string {
+	lines := make([]string, 0, len(before)+len(after))
+
+	for i, field := range before {
+		if field == after[i] {
+			lines = append(lines, fmt.Sprintf("  %s: %s", field.name, field.value))
+
+			continue
+		}
+
+		lines = append(lines,
+			fmt.Sprintf("- %s: %s", field.name, field.value),
+			fmt.Sprintf("+ %s: %s", after[i].name, after[i].value))
+	}
+
+	return "```diff\n" + strings.Join(lines, "\n") + "\n```\n"
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"gitlab.com/slxh/matrix/bot"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Status returns the status of Alertmanager, its cluster and its receivers.
+// The health of all configured nodes is checked and shown if there are multiple.
+// A degraded cluster and unavailable nodes are shown in the color of critical alerts.
+func (c *Client) Status(ctx context.Context) *bot.Message {
+	nodes, status, err := c.backend(ctx).CheckHealth(ctx)
+
+	var plain, htm string
+
+	if err == nil {
+		var receivers []string
+
+		receivers, err = c.backend(ctx).GetReceivers(ctx)
+		if err == nil {
+			plain, htm = c.formatStatus(status, receivers, c.backend(ctx).ExpectedPeers)
+		}
+	}
+
+	if err != nil {
+		plain, htm = err.Error()+"\n", html.EscapeString(err.Error())+"<br/>"
+	}
+
+	if len(nodes) > 1 {
+		p, h := c.formatNodes(nodes)
+		plain, htm = plain+p, htm+h
+	}
+
+	return bot.NewHTMLMessage(plain, htm)
+}
+
+// formatStatus formats the status of Alertmanager as plain text and HTML.
+// The cluster is degraded if it is not ready or has fewer than the expected number of peers.
+func (c *Client) formatStatus(status *alertmanager.Status, receivers []string,
+	expectedPeers int,
+) (plainContent, htmlContent string) {
+	var plain, htm strings.Builder
+
+	line := func(name, value, htmlValue string) {
+		fmt.Fprintf(&plain, "%s: %s\n", name, value)
+		fmt.Fprintf(&htm, "<b>%s:</b> %s<br/>", name, htmlValue)
+	}
+
+	version := fmt.Sprintf("%s (revision %s)", status.Version(), status.Revision())
+	line("Version", version, html.EscapeString(version))
+
+	uptime := time.Since(status.StartedAt()).Round(time.Minute).String()
+	line("Uptime", uptime, uptime)
+
+	cluster := status.ClusterStatus()
+	if cluster != alertmanager.ClusterDisabled {
+		cluster = fmt.Sprintf("%s, %d peers", cluster, len(status.Peers()))
+	}
+
+	color := "resolved"
+	if status.Degraded(expectedPeers) {
+		color, cluster = "critical", "degraded ("+cluster+")"
+	}
+
+	line("Cluster", cluster,
+		fmt.Sprintf(`<font color="%s">%s</font>`, c.Formatter.color(color), html.EscapeString(cluster)))
+
+	for _, peer := range status.Peers() {
+		p := fmt.Sprintf("%s (%s)", util.ValueOrDefault(peer.Name), util.ValueOrDefault(peer.Address))
+
+		fmt.Fprintf(&plain, "- %s\n", p)
+		fmt.Fprintf(&htm, "- <code>%s</code><br/>", html.EscapeString(p))
+	}
+
+	hash := status.ConfigHash()
+	line("Config hash", hash, "<code>"+hash+"</code>")
+
+	codes := make([]string, len(receivers))
+	for i, r := range receivers {
+		codes[i] = "<code>" + html.EscapeString(r) + "</code>"
+	}
+
+	line("Receivers", strings.Join(receivers, ", "), strings.Join(codes, ", "))
+
+	return plain.String(), htm.String()
+}
+
+// formatNodes formats the health of Alertmanager nodes as plain text and HTML.
+func (c *Client) formatNodes(nodes []alertmanager.NodeStatus) (plainContent, htmlContent string) {
+	var plain, htm strings.Builder
+
+	plain.WriteString("Nodes:\n")
+	htm.WriteString("<b>Nodes:</b><br/>")
+
+	for _, n := range nodes {
+		color, health := "resolved", "available"
+		if !n.Healthy {
+			color, health = "critical", "unavailable: "+n.Error.Error()
+		}
+
+		fmt.Fprintf(&plain, "- %s: %s\n", n.URL, health)
+		fmt.Fprintf(&htm, `- <code>%s</code>: <font color="%s">%s</font><br/>`,
+			html.EscapeString(n.URL), c.Formatter.color(color), html.EscapeString(health))
+	}
+
+	return plain.String(), htm.String()
+}
@@ -0,0 +1,933 @@
+// Package config contains the configuration file format of the Alertmanager/Matrix bot.
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/exporter-toolkit/web"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/bot"
+)
+
+// Default configuration values.
+const (
+	DefaultHomeserver       = "http://localhost:8008"
+	DefaultAlertmanagerURL  = "http://localhost:9093"
+	DefaultAddress          = ":4051"
+	DefaultMessageType      = "m.notice"
+	DefaultLogLevel         = "info"
+	DefaultConfirmThreshold = 10
+)
+
+var (
+	errRequired    = errors.New("value is required")
+	errNotPositive = errors.New("value must be positive")
+
+	errNoCredentials       = errors.New("either bearer_token or basic_auth is required")
+	errMultipleCredentials = errors.New("only one of bearer_token or basic_auth can be set")
+	errIncompleteBasicAuth = errors.New("basic_auth requires a username and password")
+	errTLSAndWebConfig     = errors.New("cannot be combined with web_config_file")
+	errAuditFileAndLog     = errors.New("cannot be combined with log")
+	errURLAndURLs          = errors.New("cannot be combined with urls")
+	errLoginWithoutStore   = errors.New("value is required to persist the session when login is configured")
+	errMaintenanceNoStore  = errors.New("value is required to remember silences when maintenance windows are configured")
+
+	errInvalidAction = errors.New("action must be one of " + ReactionAck + " or " + ReactionSilence)
+	errNegative      = errors.New("value cannot be negative")
+	errDuplicateName = errors.New("name must be unique")
+
+	errUnknownAlertmanager  = errors.New("unknown Alertmanager")
+	errReservedAlertmanager = errors.New("name is reserved for the default Alertmanager")
+
+	errInvalidURL  = errors.New("URL must contain a scheme and host")
+	errInvalidRoom = errors.New("room must be a room ID (!id:server) or alias (#alias:server)")
+)
+
+// Config represents the configuration file.
+type Config struct {
+	// Homeserver is the URL of the Matrix homeserver.
+	Homeserver string `yaml:"homeserver"`
+
+	// UserID is the Matrix user ID of the bot.
+	UserID string `yaml:"user_id"`
+
+	// Token is the Matrix access token of the bot.
+	// It is not required if login credentials are configured.
+	Token string `yaml:"token"`
+
+	// Login contains the credentials the bot logs in with when no token is set, or when the token is revoked.
+	Login Login `yaml:"login"`
+
+	// Encryption contains the configuration of end-to-end encryption.
+	Encryption Encryption `yaml:"encryption"`
+
+	// MessageType is the Matrix message type used by the bot.
+	MessageType string `yaml:"message_type"`
+
+	// Rooms contains the rooms the bot joins and accepts commands from.
+	// All rooms are allowed when empty.
+	Rooms StringList `yaml:"rooms"`
+
+	// AliasTTL is the duration that resolved room aliases are cached for.
+	AliasTTL time.Duration `yaml:"alias_ttl"`
+
+	// Alertmanager contains the configuration of the Alertmanager API.
+	Alertmanager Alertmanager `yaml:"alertmanager"`
+
+	// Alertmanagers contains additional Alertmanager backends, keyed by name.
+	// These can be selected in commands using `@name`.
+	Alertmanagers map[string]Alertmanager `yaml:"alertmanagers"`
+
+	// RoomAlertmanagers contains the names of the default Alertmanager backends of rooms, keyed by room ID or alias.
+	RoomAlertmanagers map[string]string `yaml:"room_alertmanagers"`
+
+	// Webhook contains the configuration of the webhook receiving alerts.
+	Webhook Webhook `yaml:"webhook"`
+
+	// Templates contains the message templates.
+	Templates Templates `yaml:"templates"`
+
+	// Colors contains the colors for the `color` template function.
+	Colors map[string]string `yaml:"colors"`
+
+	// Icons contains the icons for the `icon` template function.
+	Icons map[string]string `yaml:"icons"`
+
+	// Store contains the configuration of the storage of the bot state.
+	Store Store `yaml:"store"`
+
+	// Reactions contains the actions for reactions to alert notifications, keyed by the reaction (emoji).
+	Reactions map[string]Reaction `yaml:"reactions"`
+
+	// Silences contains the configuration of silences created from Matrix.
+	Silences Silences `yaml:"silences"`
+
+	// Maintenance contains the configuration of recurring maintenance windows.
+	Maintenance Maintenance `yaml:"maintenance"`
+
+	// Permissions contains the permissions required for bot commands.
+	Permissions Permissions `yaml:"permissions"`
+
+	// Audit contains the configuration of the audit log of actions that change silences or alerts.
+	Audit Audit `yaml:"audit"`
+
+	// RoomFilters contains the default filters for listing alerts in rooms, keyed by room ID or alias.
+	RoomFilters map[string]AlertFilter `yaml:"room_filters"`
+
+	// LogLevel is the minimum level of log messages.
+	LogLevel string `yaml:"log_level"`
+}
+
+// Login contains the credentials the bot logs in with.
+type Login struct {
+	// Password is the password of the bot user.
+	Password string `yaml:"password"`
+
+	// SharedSecretAuth is the secret of the devture shared secret authenticator module of the homeserver
+	// (`com.devture.shared_secret_auth`). This is not the registration shared secret of the homeserver.
+	// It is used instead of the password when set.
+	SharedSecretAuth string `yaml:"shared_secret_auth"`
+}
+
+// Configured returns true if login credentials are set.
+func (l *Login) Configured() bool {
+	return l.Password != "" || l.SharedSecretAuth != ""
+}
+
+// Encryption contains the configuration of end-to-end encryption.
+// Encryption is enabled when a store is configured.
+type Encryption struct {
+	// Store is the path to the database file the encryption keys are stored in.
+	Store string `yaml:"store"`
+
+	// PickleKey is the key the encryption keys are encrypted with in the store.
+	PickleKey string `yaml:"pickle_key"`
+
+	// DeviceID is the ID of the device of the access token.
+	// It is requested from the homeserver when empty.
+	DeviceID string `yaml:"device_id"`
+
+	// RecoveryKey is the recovery key of the cross-signing keys of the bot user.
+	// The device of the bot is verified using the cross-signing keys when set.
+	RecoveryKey string `yaml:"recovery_key"`
+}
+
+// Alertmanager contains the configuration of the Alertmanager API.
+type Alertmanager struct {
+	// URL is the base URL of the Alertmanager.
+	// The default Alertmanager uses [DefaultAlertmanagerURL] if neither URL nor URLs is set.
+	URL string `yaml:"url"`
+
+	// URLs contains the base URLs of the nodes of a highly available Alertmanager cluster.
+	// The nodes are tried in order. URL cannot be set as well.
+	URLs StringList `yaml:"urls"`
+
+	// HTTPConfig contains the configuration of the HTTP client for the Alertmanager API,
+	// such as basic authentication, bearer tokens, custom headers, TLS and the proxy.
+	// The format is the `http_config` of Prometheus and Alertmanager.
+	// Relative file names are relative to the directory of the configuration file.
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config"`
+
+	// ClusterPeers is the expected number of peers in the Alertmanager cluster.
+	// The cluster is reported as degraded when it has fewer peers. This is not checked when zero.
+	ClusterPeers int `yaml:"cluster_peers"`
+}
+
+// NodeURLs returns the base URLs of the Alertmanager nodes.
+func (a *Alertmanager) NodeURLs() []string {
+	if len(a.URLs) > 0 {
+		return a.URLs
+	}
+
+	return []string{cmp.Or(a.URL, DefaultAlertmanagerURL)}
+}
+
+// HTTPClient returns the HTTP client for the Alertmanager API.
+func (a *Alertmanager) HTTPClient() (*http.Client, error) {
+	config := commoncfg.DefaultHTTPClientConfig
+	if a.HTTPConfig != nil {
+		config = *a.HTTPConfig
+	}
+
+	client, err := commoncfg.NewClientFromConfig(config, "alertmanager")
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP configuration: %w", err)
+	}
+
+	return client, nil
+}
+
+func (a *Alertmanager) validateHTTPConfig() error {
+	if a.HTTPConfig == nil {
+		return nil
+	}
+
+	return a.HTTPConfig.Validate() //nolint:wrapcheck // transparent wrapper
+}
+
+// Reaction actions.
+const (
+	ReactionAck     = "ack"
+	ReactionSilence = "silence"
+)
+
+// Reaction contains the action for a reaction to an alert notification.
+type Reaction struct {
+	// Action is the action for the reaction.
+	// This is `ack` to acknowledge the alerts, or `silence` to acknowledge and silence the alerts.
+	Action string `yaml:"action"`
+
+	// Duration is the duration of the silence created by the `silence` action.
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Silences contains the configuration of silences created from Matrix.
+type Silences struct {
+	// ConfirmThreshold is the number of matching alerts above which a new silence must be confirmed.
+	// Confirmation is disabled when zero.
+	ConfirmThreshold int `yaml:"confirm_threshold"`
+
+	// Timezone is the default IANA time zone of times in silence commands, such as `Europe/Amsterdam`.
+	// The local time zone is used when empty.
+	Timezone string `yaml:"timezone"`
+
+	// UserTimezones contains the time zones of users, keyed by user ID.
+	// These take precedence over the time zones of rooms.
+	UserTimezones map[string]string `yaml:"user_timezones"`
+
+	// RoomTimezones contains the time zones of rooms, keyed by room ID or alias.
+	RoomTimezones map[string]string `yaml:"room_timezones"`
+}
+
+// Permissions contains the permissions required for bot commands.
+// All users in allowed rooms have a permission if it has no requirements.
+type Permissions struct {
+	// Read is the permission required for all commands.
+	Read Permission `yaml:"read"`
+
+	// Write is the permission required for commands and reactions that change silences or alerts.
+	Write Permission `yaml:"write"`
+}
+
+// Permission contains the requirements for a permission.
+// A user has the permission if any of the requirements is met.
+type Permission struct {
+	// PowerLevel is the minimum power level of the user in the room.
+	PowerLevel *int `yaml:"power_level"`
+
+	// Users contains the IDs of users that have the permission.
+	Users StringList `yaml:"users"`
+
+	// Servers contains the homeservers of users that have the permission, e.g. `example.com`.
+	Servers StringList `yaml:"servers"`
+}
+
+// Audit contains the configuration of the audit log of actions that change silences or alerts.
+// Actions are always recorded in the store, and can be shown using the `audit` command.
+type Audit struct {
+	// File is the path to a file that actions are appended to as JSON lines.
+	File string `yaml:"file"`
+
+	// Log enables logging actions as structured log messages. This cannot be combined with File.
+	Log bool `yaml:"log"`
+
+	// Room is the room ID or alias of a room that actions are posted in.
+	Room string `yaml:"room"`
+}
+
+// AlertFilter contains a filter for listing alerts.
+type AlertFilter struct {
+	// Matchers contains the label matchers in the Alertmanager format, e.g. `team="db"`.
+	Matchers StringList `yaml:"matchers"`
+
+	// Receiver is a regular expression matching the receivers of alerts.
+	Receiver string `yaml:"receiver"`
+}
+
+// ParseMatchers returns the parsed matchers of the filter.
+func (f *AlertFilter) ParseMatchers() (labels.Matchers, error) {
+	return parseMatchers(f.Matchers)
+}
+
+// Maintenance contains the configuration of recurring maintenance windows.
+type Maintenance struct {
+	// Lookahead is the duration ahead of a maintenance window that its silence is created.
+	Lookahead time.Duration `yaml:"lookahead"`
+
+	// Windows contains the maintenance windows.
+	Windows []MaintenanceWindow `yaml:"windows"`
+}
+
+// MaintenanceWindow contains the configuration of a recurring maintenance window.
+// A silence is created for every occurrence of the window.
+type MaintenanceWindow struct {
+	// Name is the unique name of the window.
+	Name string `yaml:"name"`
+
+	// Schedule is the start of the window in the standard cron format, e.g. `0 2 * * SUN`.
+	Schedule string `yaml:"schedule"`
+
+	// Timezone is the IANA time zone of the schedule.
+	// The time zone of silences is used when empty.
+	Timezone string `yaml:"timezone"`
+
+	// Duration is the duration of the window.
+	Duration time.Duration `yaml:"duration"`
+
+	// Matchers contains the label matchers of the silence in the Alertmanager format, e.g. `team="db"`.
+	Matchers StringList `yaml:"matchers"`
+
+	// Comment is the comment of the silence.
+	Comment string `yaml:"comment"`
+
+	// Rooms contains the rooms that new silences for the window are announced in.
+	Rooms StringList `yaml:"rooms"`
+
+	// Alertmanager is the name of the Alertmanager backend the silences are created in.
+	// The default backend is used when empty.
+	Alertmanager string `yaml:"alertmanager"`
+}
+
+// ParseSchedule returns the parsed schedule of the window.
+// The time zone of the window is used if set, or the given default time zone otherwise.
+func (w *MaintenanceWindow) ParseSchedule(defaultTimezone string) (cron.Schedule, error) {
+	spec := w.Schedule
+
+	if tz := cmp.Or(w.Timezone, defaultTimezone); tz != "" && !strings.HasPrefix(spec, "CRON_TZ=") {
+		spec = "CRON_TZ=" + tz + " " + spec
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", w.Schedule, err)
+	}
+
+	return schedule, nil
+}
+
+// ParseMatchers returns the parsed matchers of the window.
+func (w *MaintenanceWindow) ParseMatchers() (labels.Matchers, error) {
+	return parseMatchers(w.Matchers)
+}
+
+// Store contains the configuration of the storage of the bot state,
+// such as sent notifications, the Matrix sync token and the audit trail.
+type Store struct {
+	// Path is the path to the database file the state is persisted in.
+	// The state is kept in memory, and lost on restart, when empty.
+	Path string `yaml:"path"`
+}
+
+// Webhook contains the configuration of the webhook receiving alerts.
+type Webhook struct {
+	// Address is the address the webhook listens on.
+	Address string `yaml:"address"`
+
+	// ShowLabels adds the labels of alerts to the messages.
+	ShowLabels bool `yaml:"show_labels"`
+
+	// EditMessages enables editing the message of the previous notification for an alert group,
+	// instead of sending a new message for every notification.
+	EditMessages bool `yaml:"edit_messages"`
+
+	// EditMaxAge is the maximum age of messages that are edited or replied to in a thread.
+	// A new message is sent if the previous message is older.
+	EditMaxAge time.Duration `yaml:"edit_max_age"`
+
+	// Threads enables sending notifications for an alert group in a thread,
+	// of which the first notification for the group is the root.
+	Threads bool `yaml:"threads"`
+
+	// Routes contains the routes for alerts posted without a room.
+	// The routes are evaluated in order.
+	Routes []Route `yaml:"routes"`
+
+	// FallbackRooms contains the rooms for alerts that match no route.
+	FallbackRooms StringList `yaml:"fallback_rooms"`
+
+	// Auth contains the credentials that are accepted for all rooms and routes.
+	// Authentication is required when any credentials are configured.
+	Auth []Credentials `yaml:"auth"`
+
+	// RoomAuth contains the credentials that are only accepted for a room.
+	// The key is the room ID or alias as given in the webhook URL.
+	RoomAuth map[string][]Credentials `yaml:"room_auth"`
+
+	// TLS contains the TLS configuration of the webhook in the `tls_server_config` format
+	// of the Prometheus exporter toolkit. TLS is enabled when a certificate is configured.
+	TLS web.TLSConfig `yaml:"tls"`
+
+	// WebConfigFile is the path to a Prometheus exporter toolkit web configuration file.
+	// It cannot be combined with TLS.
+	WebConfigFile string `yaml:"web_config_file"`
+}
+
+// TLSEnabled returns true if TLS is configured for the webhook.
+func (w *Webhook) TLSEnabled() bool {
+	return w.TLS.TLSCertPath != "" || w.TLS.TLSCert != ""
+}
+
+// Credentials contains credentials for HTTP authentication.
+// Either a bearer token or basic authentication must be configured.
+type Credentials struct {
+	BearerToken string     `yaml:"bearer_token"`
+	BasicAuth   *BasicAuth `yaml:"basic_auth"`
+}
+
+// BasicAuth contains credentials for HTTP basic authentication.
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Route contains the configuration of a webhook route.
+type Route struct {
+	// Matchers contains the label matchers in the Alertmanager format, e.g. `team="db"`.
+	// An alert must match all matchers. All alerts match if no matchers are given.
+	Matchers StringList `yaml:"matchers"`
+
+	// Rooms contains the rooms that matching alerts are sent to.
+	Rooms StringList `yaml:"rooms"`
+
+	// Continue continues evaluating the next routes if an alert matches this route.
+	Continue bool `yaml:"continue"`
+
+	// Alertmanager is the name of the Alertmanager backend of the alerts matching this route.
+	Alertmanager string `yaml:"alertmanager"`
+
+	// Auth contains the credentials that are only accepted for this route.
+	Auth []Credentials `yaml:"auth"`
+}
+
+// ParseMatchers returns the parsed matchers of the route.
+func (r *Route) ParseMatchers() (labels.Matchers, error) {
+	return parseMatchers(r.Matchers)
+}
+
+// parseMatchers parses a list of matchers in the Alertmanager format.
+func parseMatchers(list StringList) (labels.Matchers, error) {
+	matchers := make(labels.Matchers, 0, len(list))
+
+	for _, s := range list {
+		ms, err := labels.ParseMatchers(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", s, err)
+		}
+
+		matchers = append(matchers, ms...)
+	}
+
+	return matchers, nil
+}
+
+// Templates contains the message templates.
+// The templates can be given inline, or be loaded from a file.
+// A template file takes precedence over an inline template.
+type Templates struct {
+	Text     string `yaml:"text"`
+	TextFile string `yaml:"text_file"`
+	HTML     string `yaml:"html"`
+	HTMLFile string `yaml:"html_file"`
+}
+
+// FieldError is returned when a configuration value is invalid.
+type FieldError struct {
+	Key string // Key contains the dotted path to the offending value.
+	Err error  // Err contains the reason the value is invalid.
+}
+
+// Error returns the error string.
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Default returns a configuration containing the default values.
+func Default() *Config {
+	return &Config{
+		Homeserver:  DefaultHomeserver,
+		MessageType: DefaultMessageType,
+		AliasTTL:    bot.DefaultAliasTTL,
+		Webhook:     Webhook{Address: DefaultAddress, EditMaxAge: bot.DefaultEditMaxAge},
+		Silences:    Silences{ConfirmThreshold: DefaultConfirmThreshold},
+		Maintenance: Maintenance{Lookahead: bot.DefaultMaintenanceLookahead},
+		LogLevel:    DefaultLogLevel,
+	}
+}
+
+// Load reads and decodes the configuration file with the given name.
+// Values not present in the file are set to their defaults.
+// The resulting configuration is not validated.
+func Load(fileName string) (*Config, error) {
+	file, err := os.Open(fileName) //nolint:gosec // file inclusion is the point
+	if err != nil {
+		return nil, fmt.Errorf("unable to open config file: %w", err)
+	}
+
+	defer file.Close() //nolint:errcheck // read-only
+
+	config, err := Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	// Resolve files in the HTTP configuration relative to the configuration file
+	if config.Alertmanager.HTTPConfig != nil {
+		config.Alertmanager.HTTPConfig.SetDirectory(filepath.Dir(fileName))
+	}
+
+	for _, am := range config.Alertmanagers {
+		if am.HTTPConfig != nil {
+			am.HTTPConfig.SetDirectory(filepath.Dir(fileName))
+		}
+	}
+
+	return config, nil
+}
+
+// Decode decodes a YAML configuration from a reader.
+// Values not present in the configuration are set to their defaults.
+// Unknown keys result in an error.
+func Decode(r io.Reader) (*Config, error) {
+	config := Default()
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unable to parse config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Encode encodes the configuration as YAML.
+func (c *Config) Encode(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2) //nolint:mnd // common YAML indentation
+
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("unable to encode config: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("unable to encode config: %w", err)
+	}
+
+	return nil
+}
+
+// String returns the configuration as YAML.
+func (c *Config) String() string {
+	buf := new(bytes.Buffer)
+
+	if err := c.Encode(buf); err != nil {
+		return err.Error()
+	}
+
+	return buf.String()
+}
+
+// Validate validates the configuration.
+// All invalid values are returned as a joined list of [FieldError].
+func (c *Config) Validate() error {
+	var errs []error
+
+	check := func(key string, err error) {
+		if err != nil {
+			errs = append(errs, &FieldError{Key: key, Err: err})
+		}
+	}
+
+	check("homeserver", validateURL(c.Homeserver))
+	check("user_id", validateUserID(c.UserID))
+	if !c.Login.Configured() {
+		check("token", validateRequired(c.Token))
+	} else if c.Store.Path == "" {
+		check("store.path", errLoginWithoutStore)
+	}
+	check("message_type", validateRequired(c.MessageType))
+	check("log_level", validateLogLevel(c.LogLevel))
+	switch {
+	case c.Alertmanager.URL == "":
+	case len(c.Alertmanager.URLs) > 0:
+		check("alertmanager.url", errURLAndURLs)
+	default:
+		check("alertmanager.url", validateURL(c.Alertmanager.URL))
+	}
+
+	check("alertmanager.http_config", c.Alertmanager.validateHTTPConfig())
+
+	for i, u := range c.Alertmanager.URLs {
+		check(fmt.Sprintf("alertmanager.urls[%d]", i), validateURL(u))
+	}
+
+	for name, am := range c.Alertmanagers {
+		key := fmt.Sprintf("alertmanagers[%q]", name)
+
+		if name == bot.DefaultAlertmanager {
+			check(key, errReservedAlertmanager)
+		}
+
+		if len(am.URLs) == 0 {
+			check(key+".url", validateURL(am.URL))
+		} else if am.URL != "" {
+			check(key+".url", errURLAndURLs)
+		}
+
+		for i, u := range am.URLs {
+			check(fmt.Sprintf("%s.urls[%d]", key, i), validateURL(u))
+		}
+
+		if am.ClusterPeers < 0 {
+			check(key+".cluster_peers", errNegative)
+		}
+
+		check(key+".http_config", am.validateHTTPConfig())
+	}
+
+	for room, name := range c.RoomAlertmanagers {
+		check(fmt.Sprintf("room_alertmanagers[%q]", room), validateRoom(room))
+		check(fmt.Sprintf("room_alertmanagers[%q]", room), c.validateAlertmanager(name))
+	}
+	check("webhook.address", validateRequired(c.Webhook.Address))
+	check("alias_ttl", validatePositive(c.AliasTTL))
+	check("webhook.edit_max_age", validatePositive(c.Webhook.EditMaxAge))
+
+	for i, room := range c.Rooms {
+		check(fmt.Sprintf("rooms[%d]", i), validateRoom(room))
+	}
+
+	for i, room := range c.Webhook.FallbackRooms {
+		check(fmt.Sprintf("webhook.fallback_rooms[%d]", i), validateRoom(room))
+	}
+
+	for i, route := range c.Webhook.Routes {
+		key := fmt.Sprintf("webhook.routes[%d]", i)
+
+		_, err := route.ParseMatchers()
+		check(key+".matchers", err)
+
+		if len(route.Rooms) == 0 {
+			check(key+".rooms", errRequired)
+		}
+
+		for j, room := range route.Rooms {
+			check(fmt.Sprintf("%s.rooms[%d]", key, j), validateRoom(room))
+		}
+
+		if route.Alertmanager != "" {
+			check(key+".alertmanager", c.validateAlertmanager(route.Alertmanager))
+		}
+
+		for j, creds := range route.Auth {
+			check(fmt.Sprintf("%s.auth[%d]", key, j), creds.validate())
+		}
+	}
+
+	for i, creds := range c.Webhook.Auth {
+		check(fmt.Sprintf("webhook.auth[%d]", i), creds.validate())
+	}
+
+	if c.Webhook.TLSEnabled() {
+		if c.Webhook.WebConfigFile != "" {
+			check("webhook.tls", errTLSAndWebConfig)
+		}
+
+		if c.Webhook.TLS.TLSKeyPath == "" && c.Webhook.TLS.TLSKey == "" {
+			check("webhook.tls.key_file", errRequired)
+		}
+	}
+
+	if c.Alertmanager.ClusterPeers < 0 {
+		check("alertmanager.cluster_peers", errNegative)
+	}
+
+	if c.Silences.ConfirmThreshold < 0 {
+		check("silences.confirm_threshold", errNegative)
+	}
+
+	if c.Silences.Timezone != "" {
+		_, err := time.LoadLocation(c.Silences.Timezone)
+		check("silences.timezone", err)
+	}
+
+	for user, tz := range c.Silences.UserTimezones {
+		check(fmt.Sprintf("silences.user_timezones[%q]", user), validateUserID(user))
+
+		check(fmt.Sprintf("silences.user_timezones[%q]", user), validateTimezone(tz))
+	}
+
+	for room, tz := range c.Silences.RoomTimezones {
+		check(fmt.Sprintf("silences.room_timezones[%q]", room), validateRoom(room))
+
+		check(fmt.Sprintf("silences.room_timezones[%q]", room), validateTimezone(tz))
+	}
+
+	check("maintenance.lookahead", validatePositive(c.Maintenance.Lookahead))
+
+	if len(c.Maintenance.Windows) > 0 && c.Store.Path == "" {
+		check("store.path", errMaintenanceNoStore)
+	}
+
+	names := make(map[string]bool, len(c.Maintenance.Windows))
+
+	for i, w := range c.Maintenance.Windows {
+		key := fmt.Sprintf("maintenance.windows[%d]", i)
+
+		check(key+".name", validateRequired(w.Name))
+
+		if names[w.Name] {
+			check(key+".name", errDuplicateName)
+		}
+
+		names[w.Name] = true
+
+		if w.Timezone != "" {
+			_, err := time.LoadLocation(w.Timezone)
+			check(key+".timezone", err)
+		}
+
+		_, err := w.ParseSchedule(c.Silences.Timezone)
+		check(key+".schedule", err)
+		check(key+".duration", validatePositive(w.Duration))
+
+		// Require matchers to avoid silencing all alerts
+		matchers, err := w.ParseMatchers()
+		if err == nil && len(matchers) == 0 {
+			err = errRequired
+		}
+
+		check(key+".matchers", err)
+
+		for j, room := range w.Rooms {
+			check(fmt.Sprintf("%s.rooms[%d]", key, j), validateRoom(room))
+		}
+
+		if w.Alertmanager != "" {
+			check(key+".alertmanager", c.validateAlertmanager(w.Alertmanager))
+		}
+	}
+
+	for key, reaction := range c.Reactions {
+		check(fmt.Sprintf("reactions[%q].action", key), reaction.validateAction())
+
+		if reaction.Duration < 0 {
+			check(fmt.Sprintf("reactions[%q].duration", key), errNegative)
+		}
+	}
+
+	checkPermission := func(key string, p *Permission) {
+		for i, user := range p.Users {
+			check(fmt.Sprintf("%s.users[%d]", key, i), validateUserID(user))
+		}
+
+		for i, server := range p.Servers {
+			check(fmt.Sprintf("%s.servers[%d]", key, i), validateRequired(server))
+		}
+	}
+
+	checkPermission("permissions.read", &c.Permissions.Read)
+	checkPermission("permissions.write", &c.Permissions.Write)
+
+	if c.Encryption.Store != "" {
+		check("encryption.pickle_key", validateRequired(c.Encryption.PickleKey))
+	}
+
+	if c.Audit.File != "" && c.Audit.Log {
+		check("audit.file", errAuditFileAndLog)
+	}
+
+	if c.Audit.Room != "" {
+		check("audit.room", validateRoom(c.Audit.Room))
+	}
+
+	for room, filter := range c.RoomFilters {
+		key := fmt.Sprintf("room_filters[%q]", room)
+
+		check(key, validateRoom(room))
+
+		_, err := filter.ParseMatchers()
+		check(key+".matchers", err)
+
+		_, err = regexp.Compile(filter.Receiver)
+		check(key+".receiver", err)
+	}
+
+	for room, list := range c.Webhook.RoomAuth {
+		check(fmt.Sprintf("webhook.room_auth[%q]", room), validateRoom(room))
+
+		for i, creds := range list {
+			check(fmt.Sprintf("webhook.room_auth[%q][%d]", room, i), creds.validate())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAlertmanager validates the name of an Alertmanager backend.
+func (c *Config) validateAlertmanager(name string) error {
+	if _, ok := c.Alertmanagers[name]; !ok && name != bot.DefaultAlertmanager {
+		return fmt.Errorf("%w: %q", errUnknownAlertmanager, name)
+	}
+
+	return nil
+}
+
+func (c *Credentials) validate() error {
+	switch {
+	case c.BearerToken != "" && c.BasicAuth != nil:
+		return errMultipleCredentials
+	case c.BearerToken == "" && c.BasicAuth == nil:
+		return errNoCredentials
+	case c.BasicAuth != nil && (c.BasicAuth.Username == "" || c.BasicAuth.Password == ""):
+		return errIncompleteBasicAuth
+	default:
+		return nil
+	}
+}
+
+func (r *Reaction) validateAction() error {
+	switch r.Action {
+	case ReactionAck, ReactionSilence:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errInvalidAction, r.Action)
+	}
+}
+
+func validateRequired(s string) error {
+	if s == "" {
+		return errRequired
+	}
+
+	return nil
+}
+
+// validateTimezone validates a required IANA time zone.
+// An empty name is rejected, as it would load UTC instead of the intended zone.
+func validateTimezone(tz string) error {
+	if err := validateRequired(tz); err != nil {
+		return err
+	}
+
+	_, err := time.LoadLocation(tz)
+
+	return err //nolint:wrapcheck // wrapped in a field error
+}
+
+func validatePositive(d time.Duration) error {
+	if d <= 0 {
+		return errNotPositive
+	}
+
+	return nil
+}
+
+func validateURL(s string) error {
+	if s == "" {
+		return errRequired
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w: %q", errInvalidURL, s)
+	}
+
+	return nil
+}
+
+func validateUserID(s string) error {
+	if s == "" {
+		return errRequired
+	}
+
+	if _, _, err := mid.UserID(s).Parse(); err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	return nil
+}
+
+func validateLogLevel(s string) error {
+	var l slog.Level
+
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+
+	return nil
+}
+
+func validateRoom(s string) error {
+	if !strings.HasPrefix(s, "!") && !strings.HasPrefix(s, "#") || !strings.Contains(s, ":") {
+		return fmt.Errorf("%w, got %q", errInvalidRoom, s)
+	}
+
+	return nil
+}
@@ -0,0 +1,411 @@
+package config_test
+
+import (
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/config"
+)
+
+// validConfig returns a minimal valid configuration.
+func validConfig() *config.Config {
+	cfg := config.Default()
+	cfg.UserID = "@bot:example.com"
+	cfg.Token = "token"
+
+	return cfg
+}
+
+// fieldKeys returns the sorted keys of the field errors in an error returned by [config.Config.Validate].
+func fieldKeys(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Validate() error %v is not a list of errors", err)
+	}
+
+	var keys []string
+
+	for _, err := range joined.Unwrap() {
+		var fieldErr *config.FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("Validate() error %v is not a field error", err)
+		}
+
+		if !strings.HasPrefix(fieldErr.Error(), fieldErr.Key+": ") {
+			t.Errorf("field error %q does not start with its key %q", fieldErr, fieldErr.Key)
+		}
+
+		keys = append(keys, fieldErr.Key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func invalidHTTPConfig() *commoncfg.HTTPClientConfig {
+	return &commoncfg.HTTPClientConfig{
+		BearerToken: "token",
+		BasicAuth:   &commoncfg.BasicAuth{Username: "user"},
+	}
+}
+
+func maintenanceWindow() config.MaintenanceWindow {
+	return config.MaintenanceWindow{
+		Name:     "backup",
+		Schedule: "0 2 * * SUN",
+		Duration: 2 * time.Hour,
+		Matchers: config.StringList{`job="db"`},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		modify func(c *config.Config)
+		keys   []string
+	}{
+		{name: "valid", modify: func(*config.Config) {}},
+		{name: "homeserver", modify: func(c *config.Config) { c.Homeserver = "localhost" }, keys: []string{"homeserver"}},
+		{name: "user_id", modify: func(c *config.Config) { c.UserID = "bot" }, keys: []string{"user_id"}},
+		{name: "token", modify: func(c *config.Config) { c.Token = "" }, keys: []string{"token"}},
+		{
+			name: "login without token",
+			modify: func(c *config.Config) {
+				c.Token, c.Login.Password, c.Store.Path = "", "password", "bot.db"
+			},
+		},
+		{
+			name:   "login without store",
+			modify: func(c *config.Config) { c.Token, c.Login.SharedSecretAuth = "", "secret" },
+			keys:   []string{"store.path"},
+		},
+		{name: "message_type", modify: func(c *config.Config) { c.MessageType = "" }, keys: []string{"message_type"}},
+		{name: "log_level", modify: func(c *config.Config) { c.LogLevel = "verbose" }, keys: []string{"log_level"}},
+		{
+			name:   "alertmanager.url",
+			modify: func(c *config.Config) { c.Alertmanager.URL = "alertmanager" },
+			keys:   []string{"alertmanager.url"},
+		},
+		{
+			name: "alertmanager.urls",
+			modify: func(c *config.Config) {
+				c.Alertmanager.URLs = config.StringList{"http://alertmanager-1:9093", "alertmanager-2"}
+			},
+			keys: []string{"alertmanager.urls[1]"},
+		},
+		{
+			name: "alertmanager.url and urls",
+			modify: func(c *config.Config) {
+				c.Alertmanager.URL = "http://alertmanager:9093"
+				c.Alertmanager.URLs = config.StringList{"http://alertmanager-1:9093"}
+			},
+			keys: []string{"alertmanager.url"},
+		},
+		{
+			name:   "alertmanager.http_config",
+			modify: func(c *config.Config) { c.Alertmanager.HTTPConfig = invalidHTTPConfig() },
+			keys:   []string{"alertmanager.http_config"},
+		},
+		{
+			name:   "alertmanager.cluster_peers",
+			modify: func(c *config.Config) { c.Alertmanager.ClusterPeers = -1 },
+			keys:   []string{"alertmanager.cluster_peers"},
+		},
+		{
+			name: "alertmanagers",
+			modify: func(c *config.Config) {
+				c.Alertmanagers = map[string]config.Alertmanager{
+					"default": {URL: "http://alertmanager:9093"},
+					"db":      {ClusterPeers: -1, HTTPConfig: invalidHTTPConfig()},
+					"ops":     {URL: "http://alertmanager-ops:9093", URLs: config.StringList{"http://alertmanager-ops:9093"}},
+					"web":     {URLs: config.StringList{"alertmanager"}},
+				}
+			},
+			keys: []string{
+				`alertmanagers["db"].cluster_peers`,
+				`alertmanagers["db"].http_config`,
+				`alertmanagers["db"].url`,
+				`alertmanagers["default"]`,
+				`alertmanagers["ops"].url`,
+				`alertmanagers["web"].urls[0]`,
+			},
+		},
+		{
+			name: "room_alertmanagers",
+			modify: func(c *config.Config) {
+				c.Alertmanagers = map[string]config.Alertmanager{"db": {URL: "http://alertmanager-db:9093"}}
+				c.RoomAlertmanagers = map[string]string{
+					"#db:example.com":  "db",
+					"#ops:example.com": "default",
+					"#web:example.com": "web",
+					"ops":              "db",
+				}
+			},
+			keys: []string{`room_alertmanagers["#web:example.com"]`, `room_alertmanagers["ops"]`},
+		},
+		{
+			name:   "webhook.address",
+			modify: func(c *config.Config) { c.Webhook.Address = "" },
+			keys:   []string{"webhook.address"},
+		},
+		{name: "alias_ttl", modify: func(c *config.Config) { c.AliasTTL = 0 }, keys: []string{"alias_ttl"}},
+		{
+			name:   "webhook.edit_max_age",
+			modify: func(c *config.Config) { c.Webhook.EditMaxAge = -time.Hour },
+			keys:   []string{"webhook.edit_max_age"},
+		},
+		{
+			name:   "rooms",
+			modify: func(c *config.Config) { c.Rooms = config.StringList{"!room:example.com", "room"} },
+			keys:   []string{"rooms[1]"},
+		},
+		{
+			name:   "webhook.fallback_rooms",
+			modify: func(c *config.Config) { c.Webhook.FallbackRooms = config.StringList{"#alerts"} },
+			keys:   []string{"webhook.fallback_rooms[0]"},
+		},
+		{
+			name: "webhook.routes",
+			modify: func(c *config.Config) {
+				c.Webhook.Routes = []config.Route{
+					{Matchers: config.StringList{`job=~"("`}, Rooms: config.StringList{"#db:example.com", "db"}},
+					{Alertmanager: "db", Auth: []config.Credentials{{}}},
+				}
+			},
+			keys: []string{
+				"webhook.routes[0].matchers",
+				"webhook.routes[0].rooms[1]",
+				"webhook.routes[1].alertmanager",
+				"webhook.routes[1].auth[0]",
+				"webhook.routes[1].rooms",
+			},
+		},
+		{
+			name: "webhook.auth",
+			modify: func(c *config.Config) {
+				c.Webhook.Auth = []config.Credentials{
+					{BearerToken: "token"},
+					{BearerToken: "token", BasicAuth: &config.BasicAuth{Username: "user", Password: "password"}},
+					{BasicAuth: &config.BasicAuth{Username: "user"}},
+				}
+			},
+			keys: []string{"webhook.auth[1]", "webhook.auth[2]"},
+		},
+		{
+			name: "webhook.room_auth",
+			modify: func(c *config.Config) {
+				c.Webhook.RoomAuth = map[string][]config.Credentials{
+					"#db:example.com": {{}},
+					"db":              {{BearerToken: "token"}},
+				}
+			},
+			keys: []string{`webhook.room_auth["#db:example.com"][0]`, `webhook.room_auth["db"]`},
+		},
+		{
+			name: "webhook.tls",
+			modify: func(c *config.Config) {
+				c.Webhook.TLS.TLSCertPath, c.Webhook.WebConfigFile = "cert.pem", "web.yml"
+			},
+			keys: []string{"webhook.tls", "webhook.tls.key_file"},
+		},
+		{
+			name:   "silences.confirm_threshold",
+			modify: func(c *config.Config) { c.Silences.ConfirmThreshold = -1 },
+			keys:   []string{"silences.confirm_threshold"},
+		},
+		{
+			name:   "silences.timezone",
+			modify: func(c *config.Config) { c.Silences.Timezone = "Nowhere/Nothing" },
+			keys:   []string{"silences.timezone"},
+		},
+		{
+			name: "silences.user_timezones",
+			modify: func(c *config.Config) {
+				c.Silences.UserTimezones = map[string]string{"@user:example.com": "", "user": "UTC"}
+			},
+			keys: []string{`silences.user_timezones["@user:example.com"]`, `silences.user_timezones["user"]`},
+		},
+		{
+			name: "silences.room_timezones",
+			modify: func(c *config.Config) {
+				c.Silences.RoomTimezones = map[string]string{"#db:example.com": "Nowhere/Nothing", "db": "UTC"}
+			},
+			keys: []string{`silences.room_timezones["#db:example.com"]`, `silences.room_timezones["db"]`},
+		},
+		{
+			name:   "maintenance.lookahead",
+			modify: func(c *config.Config) { c.Maintenance.Lookahead = 0 },
+			keys:   []string{"maintenance.lookahead"},
+		},
+		{
+			name: "maintenance window",
+			modify: func(c *config.Config) {
+				c.Store.Path = "bot.db"
+				c.Maintenance.Windows = []config.MaintenanceWindow{maintenanceWindow()}
+			},
+		},
+		{
+			name:   "maintenance without store",
+			modify: func(c *config.Config) { c.Maintenance.Windows = []config.MaintenanceWindow{maintenanceWindow()} },
+			keys:   []string{"store.path"},
+		},
+		{
+			name: "maintenance.windows",
+			modify: func(c *config.Config) {
+				c.Store.Path = "bot.db"
+				c.Maintenance.Windows = []config.MaintenanceWindow{
+					maintenanceWindow(),
+					{
+						Name:         "backup",
+						Schedule:     "at 2",
+						Timezone:     "Nowhere/Nothing",
+						Rooms:        config.StringList{"ops"},
+						Alertmanager: "db",
+					},
+					{Matchers: config.StringList{`job=~"("`}, Schedule: "@daily", Duration: time.Hour},
+				}
+			},
+			keys: []string{
+				"maintenance.windows[1].alertmanager",
+				"maintenance.windows[1].duration",
+				"maintenance.windows[1].matchers",
+				"maintenance.windows[1].name",
+				"maintenance.windows[1].rooms[0]",
+				"maintenance.windows[1].schedule",
+				"maintenance.windows[1].timezone",
+				"maintenance.windows[2].matchers",
+				"maintenance.windows[2].name",
+			},
+		},
+		{
+			name: "reactions",
+			modify: func(c *config.Config) {
+				c.Reactions = map[string]config.Reaction{
+					"👀": {Action: config.ReactionAck},
+					"🔇": {Action: config.ReactionSilence, Duration: -time.Hour},
+					"❓": {Action: "explain"},
+				}
+			},
+			keys: []string{`reactions["❓"].action`, `reactions["🔇"].duration`},
+		},
+		{
+			name: "permissions",
+			modify: func(c *config.Config) {
+				c.Permissions.Read.Users = config.StringList{"@user:example.com", "user"}
+				c.Permissions.Write.Servers = config.StringList{""}
+			},
+			keys: []string{"permissions.read.users[1]", "permissions.write.servers[0]"},
+		},
+		{
+			name:   "encryption.pickle_key",
+			modify: func(c *config.Config) { c.Encryption.Store = "crypto.db" },
+			keys:   []string{"encryption.pickle_key"},
+		},
+		{
+			name:   "audit.file",
+			modify: func(c *config.Config) { c.Audit.File, c.Audit.Log = "audit.log", true },
+			keys:   []string{"audit.file"},
+		},
+		{name: "audit.room", modify: func(c *config.Config) { c.Audit.Room = "audit" }, keys: []string{"audit.room"}},
+		{
+			name: "room_filters",
+			modify: func(c *config.Config) {
+				c.RoomFilters = map[string]config.AlertFilter{
+					"#db:example.com": {Matchers: config.StringList{`job=~"("`}, Receiver: "("},
+					"db":              {Receiver: "db"},
+				}
+			},
+			keys: []string{
+				`room_filters["#db:example.com"].matchers`,
+				`room_filters["#db:example.com"].receiver`,
+				`room_filters["db"]`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := validConfig()
+			test.modify(cfg)
+
+			if got := fieldKeys(t, cfg.Validate()); !slices.Equal(got, test.keys) {
+				t.Errorf("Validate() error keys = %q, want %q", got, test.keys)
+			}
+		})
+	}
+}
+
+func TestValidateFlags(t *testing.T) {
+	t.Parallel()
+
+	const yaml = "user_id: \"@bot:example.com\"\ntoken: token\n"
+
+	tests := []struct {
+		name string
+		args []string
+		keys []string
+	}{
+		{name: "config only"},
+		{name: "override token", args: []string{"-token", "other"}},
+		{name: "empty token", args: []string{"-token", ""}, keys: []string{"token"}},
+		{name: "password without store", args: []string{"-token", "", "-password", "secret"}, keys: []string{"store.path"}},
+		{name: "password with store", args: []string{"-token", "", "-password", "secret", "-store", "bot.db"}},
+		{name: "invalid user ID", args: []string{"-user-id", "bot"}, keys: []string{"user_id"}},
+		{name: "alertmanager", args: []string{"-alertmanager", "http://alertmanager:9093"}},
+		{
+			name: "alertmanager nodes",
+			args: []string{"-alertmanager-urls", "http://alertmanager-1:9093,alertmanager-2"},
+			keys: []string{"alertmanager.urls[1]"},
+		},
+		{
+			name: "alertmanager and nodes",
+			args: []string{"-alertmanager", "http://alertmanager:9093", "-alertmanager-urls", "http://alertmanager-1:9093"},
+			keys: []string{"alertmanager.url"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.Decode(strings.NewReader(yaml))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			// Flags override the configuration file, as in the main package
+			flags := flag.NewFlagSet(test.name, flag.ContinueOnError)
+			flags.StringVar(&cfg.UserID, "user-id", cfg.UserID, "")
+			flags.StringVar(&cfg.Token, "token", cfg.Token, "")
+			flags.StringVar(&cfg.Login.Password, "password", cfg.Login.Password, "")
+			flags.StringVar(&cfg.Store.Path, "store", cfg.Store.Path, "")
+			flags.StringVar(&cfg.Alertmanager.URL, "alertmanager", cfg.Alertmanager.URL, "")
+			flags.Var(&cfg.Alertmanager.URLs, "alertmanager-urls", "")
+
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := fieldKeys(t, cfg.Validate()); !slices.Equal(got, test.keys) {
+				t.Errorf("Validate() error keys = %q, want %q", got, test.keys)
+			}
+		})
+	}
+}
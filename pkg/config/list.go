@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+)
+
+// StringList represents a list of strings.
+// It implements [flag.Value] using a comma-separated representation,
+// allowing lists in the configuration file to be overridden by flags and environment variables.
+type StringList []string
+
+// String returns the comma-separated representation of the list.
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+// Set replaces the list with the values from a comma-separated string.
+func (l *StringList) Set(s string) error {
+	*l = nil
+
+	for v := range strings.SplitSeq(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,460 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	mid "maunium.net/go/mautrix/id"
+)
+
+// Bucket names.
+var (
+	syncBucket         = []byte("sync")          //nolint:gochecknoglobals // used as constant
+	notificationBucket = []byte("notifications") //nolint:gochecknoglobals // used as constant
+	ackBucket          = []byte("acks")          //nolint:gochecknoglobals // used as constant
+	maintenanceBucket  = []byte("maintenance")   //nolint:gochecknoglobals // used as constant
+	auditBucket        = []byte("audit")         //nolint:gochecknoglobals // used as constant
+)
+
+// boltTimeout is the maximum time to wait for the lock on the database file.
+const boltTimeout = 5 * time.Second
+
+// errStop stops iteration over a bucket.
+var errStop = errors.New("stop")
+
+// Bolt is a store that persists all state in a bbolt database file.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens or creates a bbolt database file as a store.
+func OpenBolt(fileName string) (*Bolt, error) {
+	db, err := bolt.Open(fileName, 0o600, &bolt.Options{Timeout: boltTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("error opening store %q: %w", fileName, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{syncBucket, notificationBucket, ackBucket, maintenanceBucket, auditBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("error initializing store %q: %w", fileName, err)
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// SaveFilterID stores the filter ID for a user.
+func (s *Bolt) SaveFilterID(_ context.Context, userID mid.UserID, filterID string) error {
+	return s.put(syncBucket, syncKey(userID, "filter_id"), filterID)
+}
+
+// LoadFilterID returns the filter ID for a user.
+func (s *Bolt) LoadFilterID(_ context.Context, userID mid.UserID) (filterID string, err error) {
+	err = s.get(syncBucket, syncKey(userID, "filter_id"), &filterID)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+
+	return
+}
+
+// SaveNextBatch stores the sync token for a user.
+func (s *Bolt) SaveNextBatch(_ context.Context, userID mid.UserID, nextBatchToken string) error {
+	return s.put(syncBucket, syncKey(userID, "next_batch"), nextBatchToken)
+}
+
+// LoadNextBatch returns the sync token for a user.
+func (s *Bolt) LoadNextBatch(_ context.Context, userID mid.UserID) (nextBatchToken string, err error) {
+	err = s.get(syncBucket, syncKey(userID, "next_batch"), &nextBatchToken)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+
+	return
+}
+
+// Session returns the login session of a user.
+func (s *Bolt) Session(_ context.Context, userID mid.UserID) (*Session, error) {
+	session := new(Session)
+
+	if err := s.get(syncBucket, syncKey(userID, "session"), session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// SetSession stores the login session of a user, replacing any previous session.
+func (s *Bolt) SetSession(_ context.Context, session *Session) error {
+	return s.put(syncBucket, syncKey(session.UserID, "session"), session)
+}
+
+// Notification returns the notification for an alert group in a room.
+func (s *Bolt) Notification(_ context.Context, roomID mid.RoomID, key string) (*Notification, error) {
+	n := new(Notification)
+
+	if err := s.get(notificationBucket, notificationKey(roomID, key), n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// NotificationByEvent returns the notification for a message in a room.
+func (s *Bolt) NotificationByEvent(_ context.Context, roomID mid.RoomID, eventID mid.EventID) (*Notification, error) {
+	var found *Notification
+
+	err := s.eachNotification(func(_ []byte, n *Notification) error {
+		if n.RoomID == roomID && n.EventID == eventID {
+			found = n
+
+			return errStop
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	return found, nil
+}
+
+// NotificationsByFingerprint returns all notifications containing the alert with the given fingerprint.
+func (s *Bolt) NotificationsByFingerprint(_ context.Context, fingerprint string) ([]*Notification, error) {
+	var notifications []*Notification
+
+	err := s.eachNotification(func(_ []byte, n *Notification) error {
+		if n.HasFingerprint(fingerprint) {
+			notifications = append(notifications, n)
+		}
+
+		return nil
+	})
+
+	return notifications, err
+}
+
+// SetNotification stores a notification, replacing any notification for the same alert group in the room.
+func (s *Bolt) SetNotification(_ context.Context, n *Notification) error {
+	return s.put(notificationBucket, notificationKey(n.RoomID, n.Key), n)
+}
+
+// DeleteNotification removes the notification for an alert group in a room.
+func (s *Bolt) DeleteNotification(_ context.Context, roomID mid.RoomID, key string) error {
+	return s.delete(notificationBucket, notificationKey(roomID, key))
+}
+
+// DeleteNotificationByEvent removes the notification for a message in a room.
+func (s *Bolt) DeleteNotificationByEvent(_ context.Context, roomID mid.RoomID, eventID mid.EventID) error {
+	return s.deleteNotifications(func(n *Notification) bool {
+		return n.RoomID == roomID && n.EventID == eventID
+	})
+}
+
+// PruneNotifications removes all notifications sent before the given time.
+func (s *Bolt) PruneNotifications(_ context.Context, before time.Time) error {
+	return s.deleteNotifications(func(n *Notification) bool {
+		return n.SentAt.Before(before)
+	})
+}
+
+// Ack returns the acknowledgement of the alert with the given fingerprint.
+func (s *Bolt) Ack(_ context.Context, fingerprint string) (*Ack, error) {
+	ack := new(Ack)
+
+	if err := s.get(ackBucket, []byte(fingerprint), ack); err != nil {
+		return nil, err
+	}
+
+	return ack, nil
+}
+
+// SetAck stores an acknowledgement, replacing any acknowledgement of the same alert.
+func (s *Bolt) SetAck(_ context.Context, ack *Ack) error {
+	return s.put(ackBucket, []byte(ack.Fingerprint), ack)
+}
+
+// DeleteAck removes the acknowledgement of the alert with the given fingerprint.
+func (s *Bolt) DeleteAck(_ context.Context, fingerprint string) error {
+	return s.delete(ackBucket, []byte(fingerprint))
+}
+
+// MaintenanceWindow returns the maintenance window with the given name and start time.
+func (s *Bolt) MaintenanceWindow(_ context.Context, name string, startsAt time.Time) (*MaintenanceWindow, error) {
+	w := new(MaintenanceWindow)
+
+	if err := s.get(maintenanceBucket, maintenanceKey(name, startsAt), w); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// SetMaintenanceWindow stores a maintenance window for which a silence was created.
+func (s *Bolt) SetMaintenanceWindow(_ context.Context, w *MaintenanceWindow) error {
+	return s.put(maintenanceBucket, maintenanceKey(w.Name, w.StartsAt), w)
+}
+
+// PruneMaintenanceWindows removes all maintenance windows that ended before the given time.
+func (s *Bolt) PruneMaintenanceWindows(_ context.Context, before time.Time) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(maintenanceBucket)
+
+		var keys [][]byte
+
+		err := b.ForEach(func(k, data []byte) error {
+			w := new(MaintenanceWindow)
+			if err := json.Unmarshal(data, w); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+
+			if w.EndsAt.Before(before) {
+				keys = append(keys, slices.Clone(k))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error pruning maintenance windows: %w", err)
+	}
+
+	return nil
+}
+
+// AddAuditEntry adds an entry to the audit trail.
+// Only the last entries are kept, see [maxAuditEntries].
+func (s *Bolt) AddAuditEntry(_ context.Context, entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding audit entry: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+
+		if err := b.Put(binary.BigEndian.AppendUint64(nil, seq), data); err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+
+		return pruneAuditEntries(b, seq)
+	})
+	if err != nil {
+		return fmt.Errorf("error storing audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// AuditEntries returns the last n entries of the audit trail, oldest first.
+// Only entries of actions requested in the given room are returned, unless the room is empty.
+func (s *Bolt) AuditEntries(_ context.Context, roomID mid.RoomID, n int) ([]*AuditEntry, error) {
+	entries := make([]*AuditEntry, 0, n)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(auditBucket).Cursor()
+
+		for k, v := c.Last(); k != nil && len(entries) < n; k, v = c.Prev() {
+			entry := new(AuditEntry)
+			if err := json.Unmarshal(v, entry); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+
+			if roomID == "" || entry.RoomID == roomID {
+				entries = append(entries, entry)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading audit trail: %w", err)
+	}
+
+	slices.Reverse(entries)
+
+	return entries, nil
+}
+
+// pruneAuditEntries removes the entries of the audit trail that are older than the last [maxAuditEntries],
+// given the sequence number of the last entry.
+// Entries are keyed by their sequence number, so the oldest entries come first.
+func pruneAuditEntries(b *bolt.Bucket, last uint64) error {
+	if last <= maxAuditEntries {
+		return nil
+	}
+
+	var keys [][]byte
+
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= last-maxAuditEntries; k, _ = c.Next() {
+		keys = append(keys, slices.Clone(k))
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err //nolint:wrapcheck // wrapped by caller
+		}
+	}
+
+	return nil
+}
+
+// Close closes the database file.
+func (s *Bolt) Close() error {
+	return s.db.Close() //nolint:wrapcheck // transparent wrapper
+}
+
+// get decodes the JSON value of a key into v.
+func (s *Bolt) get(bucket, key []byte, v any) error {
+	var data []byte
+
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		data = slices.Clone(tx.Bucket(bucket).Get(key))
+
+		return nil
+	})
+
+	if data == nil {
+		return ErrNotFound
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding %s: %w", bucket, err)
+	}
+
+	return nil
+}
+
+// put stores v encoded as JSON under a key.
+func (s *Bolt) put(bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", bucket, err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, data)
+	})
+	if err != nil {
+		return fmt.Errorf("error storing %s: %w", bucket, err)
+	}
+
+	return nil
+}
+
+// delete removes a key.
+func (s *Bolt) delete(bucket, key []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting %s: %w", bucket, err)
+	}
+
+	return nil
+}
+
+// eachNotification calls f for every stored notification.
+// Iteration stops without error if f returns errStop.
+func (s *Bolt) eachNotification(f func(k []byte, n *Notification) error) error {
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(notificationBucket).ForEach(func(k, data []byte) error {
+			n := new(Notification)
+			if err := json.Unmarshal(data, n); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+
+			return f(k, n)
+		})
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return fmt.Errorf("error reading notifications: %w", err)
+	}
+
+	return nil
+}
+
+// deleteNotifications removes all notifications for which f returns true.
+func (s *Bolt) deleteNotifications(f func(n *Notification) bool) error {
+	var keys [][]byte
+
+	err := s.eachNotification(func(k []byte, n *Notification) error {
+		if f(n) {
+			keys = append(keys, slices.Clone(k))
+		}
+
+		return nil
+	})
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(notificationBucket)
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting notifications: %w", err)
+	}
+
+	return nil
+}
+
+// syncKey returns the key of a sync value for a user.
+func syncKey(userID mid.UserID, name string) []byte {
+	return []byte(userID.String() + "\x00" + name)
+}
+
+// maintenanceKey returns the key of a maintenance window.
+func maintenanceKey(name string, startsAt time.Time) []byte {
+	return binary.BigEndian.AppendUint64([]byte(name+"\x00"), uint64(startsAt.Unix())) //nolint:gosec // times after 1970
+}
+
+// notificationKey returns the key of a notification.
+func notificationKey(roomID mid.RoomID, key string) []byte {
+	return []byte(roomID.String() + "\x00" + key)
+}
@@ -0,0 +1,287 @@
+package store
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"maunium.net/go/mautrix"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/internal/util"
+)
+
+// notificationID identifies a notification in a room.
+type notificationID struct {
+	roomID mid.RoomID
+	key    string
+}
+
+// Memory is a store that keeps all state in memory.
+// The state is lost when the bot is stopped.
+// Stored values are copied, like in the persistent store.
+type Memory struct {
+	*mautrix.MemorySyncStore
+
+	mu            sync.Mutex
+	sessions      map[mid.UserID]*Session
+	notifications map[notificationID]*Notification
+	acks          map[string]*Ack
+	maintenance   map[maintenanceID]*MaintenanceWindow
+	audit         []*AuditEntry
+}
+
+// maintenanceID identifies an occurrence of a maintenance window.
+type maintenanceID struct {
+	name     string
+	startsAt int64
+}
+
+// NewMemory returns a new in-memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		MemorySyncStore: mautrix.NewMemorySyncStore(),
+		sessions:        make(map[mid.UserID]*Session),
+		notifications:   make(map[notificationID]*Notification),
+		acks:            make(map[string]*Ack),
+		maintenance:     make(map[maintenanceID]*MaintenanceWindow),
+	}
+}
+
+// Session returns the login session of a user.
+func (s *Memory) Session(_ context.Context, userID mid.UserID) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return util.PtrTo(*session), nil
+}
+
+// SetSession stores the login session of a user, replacing any previous session.
+func (s *Memory) SetSession(_ context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.UserID] = util.PtrTo(*session)
+
+	return nil
+}
+
+// Notification returns the notification for an alert group in a room.
+func (s *Memory) Notification(_ context.Context, roomID mid.RoomID, key string) (*Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifications[notificationID{roomID, key}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return copyNotification(n), nil
+}
+
+// NotificationByEvent returns the notification for a message in a room.
+func (s *Memory) NotificationByEvent(_ context.Context, roomID mid.RoomID, eventID mid.EventID) (*Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, n := range s.notifications {
+		if id.roomID == roomID && n.EventID == eventID {
+			return copyNotification(n), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// NotificationsByFingerprint returns all notifications containing the alert with the given fingerprint.
+func (s *Memory) NotificationsByFingerprint(_ context.Context, fingerprint string) ([]*Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []*Notification
+
+	for _, n := range s.notifications {
+		if n.HasFingerprint(fingerprint) {
+			notifications = append(notifications, copyNotification(n))
+		}
+	}
+
+	return notifications, nil
+}
+
+// SetNotification stores a notification, replacing any notification for the same alert group in the room.
+func (s *Memory) SetNotification(_ context.Context, n *Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notifications[notificationID{n.RoomID, n.Key}] = copyNotification(n)
+
+	return nil
+}
+
+// DeleteNotification removes the notification for an alert group in a room.
+func (s *Memory) DeleteNotification(_ context.Context, roomID mid.RoomID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.notifications, notificationID{roomID, key})
+
+	return nil
+}
+
+// DeleteNotificationByEvent removes the notification for a message in a room.
+func (s *Memory) DeleteNotificationByEvent(_ context.Context, roomID mid.RoomID, eventID mid.EventID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, n := range s.notifications {
+		if id.roomID == roomID && n.EventID == eventID {
+			delete(s.notifications, id)
+		}
+	}
+
+	return nil
+}
+
+// PruneNotifications removes all notifications sent before the given time.
+func (s *Memory) PruneNotifications(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, n := range s.notifications {
+		if n.SentAt.Before(before) {
+			delete(s.notifications, id)
+		}
+	}
+
+	return nil
+}
+
+// Ack returns the acknowledgement of the alert with the given fingerprint.
+func (s *Memory) Ack(_ context.Context, fingerprint string) (*Ack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ack, ok := s.acks[fingerprint]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return util.PtrTo(*ack), nil
+}
+
+// SetAck stores an acknowledgement, replacing any acknowledgement of the same alert.
+func (s *Memory) SetAck(_ context.Context, ack *Ack) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.acks[ack.Fingerprint] = util.PtrTo(*ack)
+
+	return nil
+}
+
+// DeleteAck removes the acknowledgement of the alert with the given fingerprint.
+func (s *Memory) DeleteAck(_ context.Context, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.acks, fingerprint)
+
+	return nil
+}
+
+// MaintenanceWindow returns the maintenance window with the given name and start time.
+func (s *Memory) MaintenanceWindow(_ context.Context, name string, startsAt time.Time) (*MaintenanceWindow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.maintenance[maintenanceID{name, startsAt.Unix()}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return util.PtrTo(*w), nil
+}
+
+// SetMaintenanceWindow stores a maintenance window for which a silence was created.
+func (s *Memory) SetMaintenanceWindow(_ context.Context, w *MaintenanceWindow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maintenance[maintenanceID{w.Name, w.StartsAt.Unix()}] = util.PtrTo(*w)
+
+	return nil
+}
+
+// PruneMaintenanceWindows removes all maintenance windows that ended before the given time.
+func (s *Memory) PruneMaintenanceWindows(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, w := range s.maintenance {
+		if w.EndsAt.Before(before) {
+			delete(s.maintenance, id)
+		}
+	}
+
+	return nil
+}
+
+// AddAuditEntry adds an entry to the audit trail.
+// Only the last entries are kept, see [maxAuditEntries].
+func (s *Memory) AddAuditEntry(_ context.Context, entry *AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit = append(s.audit, util.PtrTo(*entry))
+	if len(s.audit) > maxAuditEntries {
+		s.audit = slices.Delete(s.audit, 0, len(s.audit)-maxAuditEntries)
+	}
+
+	return nil
+}
+
+// AuditEntries returns the last n entries of the audit trail, oldest first.
+// Only entries of actions requested in the given room are returned, unless the room is empty.
+func (s *Memory) AuditEntries(_ context.Context, roomID mid.RoomID, n int) ([]*AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []*AuditEntry
+
+	for _, entry := range slices.Backward(s.audit) {
+		if len(entries) == n {
+			break
+		}
+
+		if roomID == "" || entry.RoomID == roomID {
+			entries = append(entries, util.PtrTo(*entry))
+		}
+	}
+
+	slices.Reverse(entries)
+
+	return entries, nil
+}
+
+// copyNotification returns a copy of a notification, so that stored notifications are not shared.
+// The alerts themselves are not modified after they are received, and are shared.
+func copyNotification(n *Notification) *Notification {
+	c := *n
+	c.Labels = maps.Clone(n.Labels)
+	c.Alerts = slices.Clone(n.Alerts)
+
+	return &c
+}
+
+// Close is a no-op for the in-memory store.
+func (*Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package store contains storage for the state of the bot.
+// The state is kept in memory, or persisted in a file so that it survives restarts.
+package store
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"maunium.net/go/mautrix"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// maxAuditEntries is the maximum number of entries of the audit trail that are kept.
+const maxAuditEntries = 1000
+
+// ErrNotFound is returned when a requested item is not in the store.
+var ErrNotFound = errors.New("not found")
+
+// Store stores the state of the bot.
+// Implementations are safe for concurrent use.
+type Store interface {
+	// SyncStore stores the Matrix sync token and filter ID,
+	// so that syncing continues where it left off after a restart.
+	mautrix.SyncStore
+
+	// Session returns the login session of a user.
+	Session(ctx context.Context, userID mid.UserID) (*Session, error)
+
+	// SetSession stores the login session of a user, replacing any previous session.
+	SetSession(ctx context.Context, session *Session) error
+
+	// Notification returns the notification for an alert group in a room.
+	Notification(ctx context.Context, roomID mid.RoomID, key string) (*Notification, error)
+
+	// NotificationByEvent returns the notification for a message in a room.
+	NotificationByEvent(ctx context.Context, roomID mid.RoomID, eventID mid.EventID) (*Notification, error)
+
+	// NotificationsByFingerprint returns all notifications containing the alert with the given fingerprint.
+	NotificationsByFingerprint(ctx context.Context, fingerprint string) ([]*Notification, error)
+
+	// SetNotification stores a notification, replacing any notification for the same alert group in the room.
+	SetNotification(ctx context.Context, n *Notification) error
+
+	// DeleteNotification removes the notification for an alert group in a room.
+	DeleteNotification(ctx context.Context, roomID mid.RoomID, key string) error
+
+	// DeleteNotificationByEvent removes the notification for a message in a room.
+	DeleteNotificationByEvent(ctx context.Context, roomID mid.RoomID, eventID mid.EventID) error
+
+	// PruneNotifications removes all notifications sent before the given time.
+	PruneNotifications(ctx context.Context, before time.Time) error
+
+	// Ack returns the acknowledgement of the alert with the given fingerprint.
+	Ack(ctx context.Context, fingerprint string) (*Ack, error)
+
+	// SetAck stores an acknowledgement, replacing any acknowledgement of the same alert.
+	SetAck(ctx context.Context, ack *Ack) error
+
+	// DeleteAck removes the acknowledgement of the alert with the given fingerprint.
+	DeleteAck(ctx context.Context, fingerprint string) error
+
+	// MaintenanceWindow returns the maintenance window with the given name and start time.
+	MaintenanceWindow(ctx context.Context, name string, startsAt time.Time) (*MaintenanceWindow, error)
+
+	// SetMaintenanceWindow stores a maintenance window for which a silence was created.
+	SetMaintenanceWindow(ctx context.Context, w *MaintenanceWindow) error
+
+	// PruneMaintenanceWindows removes all maintenance windows that ended before the given time.
+	PruneMaintenanceWindows(ctx context.Context, before time.Time) error
+
+	// AddAuditEntry adds an entry to the audit trail.
+	AddAuditEntry(ctx context.Context, entry *AuditEntry) error
+
+	// AuditEntries returns the last n entries of the audit trail, oldest first.
+	// Only entries of actions requested in the given room are returned, unless the room is empty.
+	AuditEntries(ctx context.Context, roomID mid.RoomID, n int) ([]*AuditEntry, error)
+
+	// Close closes the store.
+	Close() error
+}
+
+// New returns a store persisted in the given file,
+// or an in-memory store if the file name is empty.
+func New(fileName string) (Store, error) {
+	if fileName == "" {
+		return NewMemory(), nil
+	}
+
+	return OpenBolt(fileName)
+}
+
+// Session represents the login session of the bot.
+type Session struct {
+	UserID      mid.UserID   `json:"user_id"`      // User that is logged in.
+	DeviceID    mid.DeviceID `json:"device_id"`    // Device created by the login.
+	AccessToken string       `json:"access_token"` // Access token of the device.
+}
+
+// Notification represents an alert notification that was sent to a room.
+type Notification struct {
+	RoomID  mid.RoomID            `json:"room_id"`  // Room the notification was sent to.
+	EventID mid.EventID           `json:"event_id"` // Event ID of the sent message.
+	Key     string                `json:"key"`      // Key identifying the alert group of the notification.
+	Labels  map[string]string     `json:"labels"`   // Labels identifying the alert group of the notification.
+	Alerts  []*alertmanager.Alert `json:"alerts"`   // Alerts in the notification.
+	SentAt  time.Time             `json:"sent_at"`  // Time the original message was sent.
+
+	// ShowLabels is true if the labels of the alerts are shown in the message.
+	ShowLabels bool `json:"show_labels"`
+
+	// Alertmanager is the name of the Alertmanager backend the alerts were received from, if known.
+	Alertmanager string `json:"alertmanager,omitempty"`
+}
+
+// Fingerprints returns the fingerprints of the alerts in the notification.
+func (n *Notification) Fingerprints() []string {
+	fingerprints := make([]string, 0, len(n.Alerts))
+
+	for _, a := range n.Alerts {
+		if a.Fingerprint != "" {
+			fingerprints = append(fingerprints, a.Fingerprint)
+		}
+	}
+
+	return fingerprints
+}
+
+// HasFingerprint returns true if the notification contains the alert with the given fingerprint.
+func (n *Notification) HasFingerprint(fingerprint string) bool {
+	return slices.Contains(n.Fingerprints(), fingerprint)
+}
+
+// Matchers returns label matchers for the alert group of the notification.
+// These match the group labels if known, or the labels that all alerts have in common otherwise.
+func (n *Notification) Matchers() labels.Matchers {
+	if len(n.Labels) == 0 {
+		return n.CommonMatchers()
+	}
+
+	return alertmanager.LabelMatchers(n.Labels)
+}
+
+// CommonMatchers returns label matchers for the labels that all alerts in the notification have in common.
+func (n *Notification) CommonMatchers() labels.Matchers {
+	return alertmanager.LabelMatchers(commonLabels(n.Alerts))
+}
+
+// commonLabels returns the labels that all alerts have in common.
+func commonLabels(alerts []*alertmanager.Alert) map[string]string {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	common := maps.Clone(alerts[0].Labels)
+
+	for _, a := range alerts[1:] {
+		maps.DeleteFunc(common, func(name, value string) bool {
+			v, ok := a.Labels[name]
+
+			return !ok || v != value
+		})
+	}
+
+	return common
+}
+
+// Ack represents the acknowledgement of an alert by a user.
+type Ack struct {
+	Fingerprint string      `json:"fingerprint"`          // Fingerprint of the acknowledged alert.
+	UserID      mid.UserID  `json:"user_id"`              // User that acknowledged the alert.
+	RoomID      mid.RoomID  `json:"room_id"`              // Room the alert was acknowledged in.
+	EventID     mid.EventID `json:"event_id"`             // Event that acknowledged the alert.
+	SilenceID   string      `json:"silence_id,omitempty"` // Silence created for the acknowledgement, if any.
+	Time        time.Time   `json:"time"`                 // Time of the acknowledgement.
+}
+
+// MaintenanceWindow represents an occurrence of a recurring maintenance window for which a silence was created.
+type MaintenanceWindow struct {
+	Name      string    `json:"name"`       // Name of the recurring maintenance window.
+	StartsAt  time.Time `json:"starts_at"`  // Start of the occurrence.
+	EndsAt    time.Time `json:"ends_at"`    // End of the occurrence.
+	SilenceID string    `json:"silence_id"` // Silence created for the occurrence.
+}
+
+// AuditEntry represents an action taken by the bot.
+type AuditEntry struct {
+	Time         time.Time  `json:"time"`                   // Time of the action.
+	UserID       mid.UserID `json:"user_id,omitempty"`      // User that requested the action, if any.
+	RoomID       mid.RoomID `json:"room_id,omitempty"`      // Room the action was requested in, if any.
+	Command      string     `json:"command,omitempty"`      // Command or reaction that requested the action, if any.
+	Action       string     `json:"action"`                 // Name of the action.
+	Alertmanager string     `json:"alertmanager,omitempty"` // Name of the Alertmanager backend, if not the default.
+	SilenceID    string     `json:"silence_id,omitempty"`   // Silence created or changed by the action, if any.
+	Matchers     string     `json:"matchers,omitempty"`     // Matchers of the silence, if any.
+	Details      string     `json:"details,omitempty"`      // Other details of the action, such as alert fingerprints.
+	Error        string     `json:"error,omitempty"`        // Error if the action failed.
+}
@@ -0,0 +1,292 @@
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/store"
+)
+
+// testStores runs a test against every store implementation.
+func testStores(t *testing.T, test func(t *testing.T, s store.Store)) {
+	t.Helper()
+
+	stores := map[string]func(t *testing.T) store.Store{
+		"memory": func(*testing.T) store.Store { return store.NewMemory() },
+		"bolt": func(t *testing.T) store.Store {
+			t.Helper()
+
+			s, err := store.OpenBolt(filepath.Join(t.TempDir(), "bot.db"))
+			if err != nil {
+				t.Fatalf("OpenBolt() error = %v", err)
+			}
+
+			t.Cleanup(func() { s.Close() })
+
+			return s
+		},
+	}
+
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			test(t, open(t))
+		})
+	}
+}
+
+func notification(room mid.RoomID, key string, event mid.EventID, sentAt time.Time) *store.Notification {
+	return &store.Notification{
+		RoomID:  room,
+		EventID: event,
+		Key:     key,
+		Labels:  map[string]string{"alertname": key},
+		Alerts:  []*alertmanager.Alert{{Alert: &template.Alert{Fingerprint: "fp-" + key}}},
+		SentAt:  sentAt,
+	}
+}
+
+func TestNotificationByEvent(t *testing.T) {
+	t.Parallel()
+
+	testStores(t, func(t *testing.T, s store.Store) {
+		now := time.Now()
+
+		for _, n := range []*store.Notification{
+			notification("!a:example.com", "db", "$1", now),
+			notification("!a:example.com", "web", "$2", now),
+			notification("!b:example.com", "db", "$2", now),
+		} {
+			if err := s.SetNotification(t.Context(), n); err != nil {
+				t.Fatalf("SetNotification() error = %v", err)
+			}
+		}
+
+		n, err := s.NotificationByEvent(t.Context(), "!b:example.com", "$2")
+		if err != nil || n.RoomID != "!b:example.com" || n.Key != "db" || !n.HasFingerprint("fp-db") {
+			t.Errorf("NotificationByEvent() = %+v, %v, want db notification in !b:example.com", n, err)
+		}
+
+		if _, err := s.NotificationByEvent(t.Context(), "!b:example.com", "$1"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("NotificationByEvent() of event in other room error = %v, want %v", err, store.ErrNotFound)
+		}
+
+		if err := s.DeleteNotificationByEvent(t.Context(), "!a:example.com", "$2"); err != nil {
+			t.Fatalf("DeleteNotificationByEvent() error = %v", err)
+		}
+
+		if _, err := s.Notification(t.Context(), "!a:example.com", "web"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Notification() of deleted notification error = %v, want %v", err, store.ErrNotFound)
+		}
+
+		if _, err := s.NotificationByEvent(t.Context(), "!b:example.com", "$2"); err != nil {
+			t.Errorf("NotificationByEvent() of event in other room after delete error = %v", err)
+		}
+	})
+}
+
+func TestPruneNotifications(t *testing.T) {
+	t.Parallel()
+
+	testStores(t, func(t *testing.T, s store.Store) {
+		now := time.Now()
+
+		for i, key := range []string{"old", "new", "newer"} {
+			n := notification("!a:example.com", key, mid.EventID(fmt.Sprint("$", i)), now.Add(time.Duration(i-1)*time.Hour))
+			if err := s.SetNotification(t.Context(), n); err != nil {
+				t.Fatalf("SetNotification() error = %v", err)
+			}
+		}
+
+		if err := s.PruneNotifications(t.Context(), now); err != nil {
+			t.Fatalf("PruneNotifications() error = %v", err)
+		}
+
+		for key, want := range map[string]error{"old": store.ErrNotFound, "new": nil, "newer": nil} {
+			if _, err := s.Notification(t.Context(), "!a:example.com", key); !errors.Is(err, want) {
+				t.Errorf("Notification(%q) after prune error = %v, want %v", key, err, want)
+			}
+		}
+
+		notifications, err := s.NotificationsByFingerprint(t.Context(), "fp-old")
+		if err != nil || len(notifications) != 0 {
+			t.Errorf("NotificationsByFingerprint() of pruned alert = %v, %v, want none", notifications, err)
+		}
+	})
+}
+
+func TestAck(t *testing.T) {
+	t.Parallel()
+
+	testStores(t, func(t *testing.T, s store.Store) {
+		want := store.Ack{
+			Fingerprint: "0123456789abcdef",
+			UserID:      "@user:example.com",
+			RoomID:      "!a:example.com",
+			EventID:     "$ack",
+			SilenceID:   "silence",
+			Time:        time.Now().Truncate(time.Second).UTC(),
+		}
+
+		if _, err := s.Ack(t.Context(), want.Fingerprint); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Ack() before SetAck() error = %v, want %v", err, store.ErrNotFound)
+		}
+
+		if err := s.SetAck(t.Context(), &want); err != nil {
+			t.Fatalf("SetAck() error = %v", err)
+		}
+
+		ack, err := s.Ack(t.Context(), want.Fingerprint)
+		if err != nil || *ack != want {
+			t.Errorf("Ack() = %+v, %v, want %+v", ack, err, want)
+		}
+
+		if err := s.DeleteAck(t.Context(), want.Fingerprint); err != nil {
+			t.Fatalf("DeleteAck() error = %v", err)
+		}
+
+		if _, err := s.Ack(t.Context(), want.Fingerprint); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Ack() after DeleteAck() error = %v, want %v", err, store.ErrNotFound)
+		}
+	})
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	t.Parallel()
+
+	testStores(t, func(t *testing.T, s store.Store) {
+		startsAt := time.Date(2026, 10, 25, 2, 0, 0, 0, time.UTC)
+
+		for _, w := range []*store.MaintenanceWindow{
+			{Name: "backup", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), SilenceID: "backup-1"},
+			{Name: "backup", StartsAt: startsAt.AddDate(0, 0, 7), EndsAt: startsAt.AddDate(0, 0, 7).Add(time.Hour),
+				SilenceID: "backup-2"},
+			{Name: "backup-db", StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), SilenceID: "backup-db-1"},
+		} {
+			if err := s.SetMaintenanceWindow(t.Context(), w); err != nil {
+				t.Fatalf("SetMaintenanceWindow() error = %v", err)
+			}
+		}
+
+		tests := []struct {
+			name     string
+			startsAt time.Time
+			want     string
+		}{
+			{name: "backup", startsAt: startsAt, want: "backup-1"},
+			{name: "backup", startsAt: startsAt.In(time.FixedZone("CEST", 2*60*60)), want: "backup-1"},
+			{name: "backup", startsAt: startsAt.AddDate(0, 0, 7), want: "backup-2"},
+			{name: "backup-db", startsAt: startsAt, want: "backup-db-1"},
+			{name: "backup", startsAt: startsAt.Add(time.Minute)},
+			{name: "backup-web", startsAt: startsAt},
+		}
+
+		for _, test := range tests {
+			w, err := s.MaintenanceWindow(t.Context(), test.name, test.startsAt)
+
+			switch {
+			case test.want == "":
+				if !errors.Is(err, store.ErrNotFound) {
+					t.Errorf("MaintenanceWindow(%q, %s) error = %v, want %v", test.name, test.startsAt, err, store.ErrNotFound)
+				}
+			case err != nil || w.SilenceID != test.want:
+				t.Errorf("MaintenanceWindow(%q, %s) = %+v, %v, want silence %q", test.name, test.startsAt, w, err, test.want)
+			}
+		}
+
+		if err := s.PruneMaintenanceWindows(t.Context(), startsAt.AddDate(0, 0, 1)); err != nil {
+			t.Fatalf("PruneMaintenanceWindows() error = %v", err)
+		}
+
+		if _, err := s.MaintenanceWindow(t.Context(), "backup", startsAt); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("MaintenanceWindow() after prune error = %v, want %v", err, store.ErrNotFound)
+		}
+
+		if _, err := s.MaintenanceWindow(t.Context(), "backup", startsAt.AddDate(0, 0, 7)); err != nil {
+			t.Errorf("MaintenanceWindow() of later window after prune error = %v", err)
+		}
+	})
+}
+
+func TestAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	testStores(t, func(t *testing.T, s store.Store) {
+		rooms := []mid.RoomID{"!a:example.com", "!b:example.com", ""}
+
+		for i := range 6 {
+			entry := &store.AuditEntry{Action: fmt.Sprint(i), RoomID: rooms[i%len(rooms)]}
+			if err := s.AddAuditEntry(t.Context(), entry); err != nil {
+				t.Fatalf("AddAuditEntry() error = %v", err)
+			}
+		}
+
+		tests := []struct {
+			room mid.RoomID
+			n    int
+			want []string
+		}{
+			{n: 10, want: []string{"0", "1", "2", "3", "4", "5"}},
+			{n: 3, want: []string{"3", "4", "5"}},
+			{room: "!a:example.com", n: 10, want: []string{"0", "3"}},
+			{room: "!b:example.com", n: 1, want: []string{"4"}},
+			{room: "!c:example.com", n: 10},
+			{n: 0},
+		}
+
+		for _, test := range tests {
+			entries, err := s.AuditEntries(t.Context(), test.room, test.n)
+			if err != nil {
+				t.Fatalf("AuditEntries() error = %v", err)
+			}
+
+			if got := actions(entries); !slices.Equal(got, test.want) {
+				t.Errorf("AuditEntries(%q, %d) = %q, want %q", test.room, test.n, got, test.want)
+			}
+		}
+	})
+}
+
+func TestAuditEntriesLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 1000
+
+	testStores(t, func(t *testing.T, s store.Store) {
+		for i := range limit + 5 {
+			if err := s.AddAuditEntry(t.Context(), &store.AuditEntry{Action: fmt.Sprint(i)}); err != nil {
+				t.Fatalf("AddAuditEntry() error = %v", err)
+			}
+		}
+
+		entries, err := s.AuditEntries(t.Context(), "", 2*limit)
+		if err != nil {
+			t.Fatalf("AuditEntries() error = %v", err)
+		}
+
+		got := actions(entries)
+		if len(got) != limit || got[0] != "5" || got[limit-1] != fmt.Sprint(limit+4) {
+			t.Errorf("AuditEntries() returned %d entries, want the last %d", len(got), limit)
+		}
+	})
+}
+
+// actions returns the actions of audit entries.
+func actions(entries []*store.AuditEntry) []string {
+	var names []string
+
+	for _, entry := range entries {
+		names = append(names, entry.Action)
+	}
+
+	return names
+}
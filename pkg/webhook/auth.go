@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Credentials contains credentials accepted by the webhook.
+// Either a bearer token, or a username and password for HTTP basic authentication are set.
+type Credentials struct {
+	BearerToken string
+	Username    string
+	Password    string
+}
+
+// Match returns true if the request contains these credentials.
+// The credentials are compared in constant time.
+func (c *Credentials) Match(r *http.Request) bool {
+	if c.BearerToken != "" {
+		token, ok := bearerToken(r)
+
+		return ok && secureCompare(token, c.BearerToken)
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	// Compare both to avoid leaking which one is invalid
+	validUsername := secureCompare(username, c.Username)
+	validPassword := secureCompare(password, c.Password)
+
+	return validUsername && validPassword
+}
+
+// Auth contains the credentials for authenticating requests to the webhook.
+// Authentication is only required when any credentials are configured.
+type Auth struct {
+	// Credentials contains the credentials that are valid for all rooms and routes.
+	Credentials []Credentials
+
+	// Rooms contains credentials that are only valid for a room.
+	// The key is the room ID or alias as given in the request path.
+	Rooms map[string][]Credentials
+}
+
+// authResult represents the result of an authentication check.
+type authResult int
+
+const (
+	authMissing   authResult = iota // no credentials were provided
+	authInvalid                     // no valid credentials were provided
+	authForbidden                   // valid credentials were provided, but not for the requested resource
+	authOK                          // valid credentials were provided
+)
+
+// String returns the reason for the authentication result.
+func (a authResult) String() string {
+	switch a {
+	case authMissing:
+		return "missing credentials"
+	case authInvalid:
+		return "invalid credentials"
+	case authForbidden:
+		return "credentials not valid for resource"
+	case authOK:
+		return "authenticated"
+	default:
+		return "unknown"
+	}
+}
+
+// status returns the HTTP status code for the authentication result.
+func (a authResult) status() int {
+	switch a {
+	case authOK:
+		return http.StatusOK
+	case authForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// anyMatch returns true if the request matches any of the given credentials.
+func anyMatch(r *http.Request, credentials []Credentials) bool {
+	for i := range credentials {
+		if credentials[i].Match(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// global returns true if the request matches the global credentials.
+func (a *Auth) global(r *http.Request) bool {
+	return anyMatch(r, a.Credentials)
+}
+
+// anyValid returns true if the request matches any configured credentials.
+func (a *Auth) anyValid(r *http.Request, router *Router) bool {
+	if a.global(r) {
+		return true
+	}
+
+	for _, credentials := range a.Rooms {
+		if anyMatch(r, credentials) {
+			return true
+		}
+	}
+
+	for _, route := range router.Routes {
+		if anyMatch(r, route.Credentials) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failure returns the reason why a request failed authentication.
+func (a *Auth) failure(r *http.Request, router *Router) authResult {
+	switch {
+	case r.Header.Get("Authorization") == "":
+		return authMissing
+	case a.anyValid(r, router):
+		return authForbidden
+	default:
+		return authInvalid
+	}
+}
+
+// enabled returns true if authentication is required.
+func (a *Auth) enabled(router *Router) bool {
+	if len(a.Credentials) > 0 || len(a.Rooms) > 0 {
+		return true
+	}
+
+	for _, route := range router.Routes {
+		if len(route.Credentials) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bearerToken returns the bearer token in the request.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	return token, true
+}
+
+// secureCompare compares two strings in constant time.
+// The strings are hashed to avoid leaking the length of the expected value.
+func secureCompare(given, expected string) bool {
+	a := sha256.Sum256([]byte(given))
+	b := sha256.Sum256([]byte(expected))
+
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// authHeader adds credentials to a request.
+type authHeader func(r *http.Request)
+
+func bearer(token string) authHeader {
+	return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+}
+
+func basic(username, password string) authHeader {
+	return func(r *http.Request) { r.SetBasicAuth(username, password) }
+}
+
+func newAuthRequest(path string, auth authHeader) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(""))
+	if auth != nil {
+		auth(r)
+	}
+
+	return r
+}
+
+func TestCredentialsMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		credentials Credentials
+		auth        authHeader
+		want        bool
+	}{
+		{name: "bearer token", credentials: Credentials{BearerToken: "token"}, auth: bearer("token"), want: true},
+		{name: "wrong bearer token", credentials: Credentials{BearerToken: "token"}, auth: bearer("other")},
+		{name: "bearer token prefix", credentials: Credentials{BearerToken: "token"}, auth: bearer("tok")},
+		{
+			name:        "lowercase scheme",
+			credentials: Credentials{BearerToken: "token"},
+			auth:        func(r *http.Request) { r.Header.Set("Authorization", "bearer token") },
+			want:        true,
+		},
+		{name: "basic instead of bearer", credentials: Credentials{BearerToken: "token"}, auth: basic("token", "token")},
+		{name: "missing bearer token", credentials: Credentials{BearerToken: "token"}},
+		{
+			name:        "basic auth",
+			credentials: Credentials{Username: "user", Password: "pass"},
+			auth:        basic("user", "pass"),
+			want:        true,
+		},
+		{name: "wrong password", credentials: Credentials{Username: "user", Password: "pass"}, auth: basic("user", "x")},
+		{name: "wrong username", credentials: Credentials{Username: "user", Password: "pass"}, auth: basic("x", "pass")},
+		{name: "bearer instead of basic", credentials: Credentials{Username: "user", Password: "pass"}, auth: bearer("pass")},
+		{name: "missing basic auth", credentials: Credentials{Username: "user", Password: "pass"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.credentials.Match(newAuthRequest("/", test.auth)); got != test.want {
+				t.Errorf("Match() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func testAuthHandler() *Handler {
+	return NewHandler(nil, &HandlerConfig{
+		Router: &Router{
+			Routes: []*Route{
+				{Rooms: []string{"#db"}, Credentials: []Credentials{{BearerToken: "db"}}},
+				{Rooms: []string{"#web"}},
+			},
+		},
+		Auth: &Auth{
+			Credentials: []Credentials{{BearerToken: "global"}},
+			Rooms:       map[string][]Credentials{"#ops": {{Username: "ops", Password: "secret"}}},
+		},
+	})
+}
+
+func TestHandlerAuthenticateRoom(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		room string
+		auth authHeader
+		want authResult
+	}{
+		{name: "global credentials", room: "#ops", auth: bearer("global"), want: authOK},
+		{name: "room credentials", room: "#ops", auth: basic("ops", "secret"), want: authOK},
+		{name: "credentials of other room", room: "#web", auth: basic("ops", "secret"), want: authForbidden},
+		{name: "route credentials", room: "#db", auth: bearer("db"), want: authForbidden},
+		{name: "invalid credentials", room: "#ops", auth: basic("ops", "wrong"), want: authInvalid},
+		{name: "missing credentials", room: "#ops", want: authMissing},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := testAuthHandler().authenticateRoom(newAuthRequest("/"+test.room, test.auth), test.room)
+			if got != test.want {
+				t.Errorf("authenticateRoom() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHandlerAuthenticateRoutes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		auth   authHeader
+		want   authResult
+		routes int
+	}{
+		{name: "global credentials", auth: bearer("global"), want: authOK, routes: 2},
+		{name: "route credentials", auth: bearer("db"), want: authOK, routes: 1},
+		{name: "room credentials", auth: basic("ops", "secret"), want: authForbidden},
+		{name: "invalid credentials", auth: bearer("wrong"), want: authInvalid},
+		{name: "missing credentials", want: authMissing},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			router, got := testAuthHandler().authenticateRoutes(newAuthRequest("/", test.auth))
+			if got != test.want {
+				t.Errorf("authenticateRoutes() = %s, want %s", got, test.want)
+			}
+
+			if got == authOK && len(router.Routes) != test.routes {
+				t.Errorf("authenticateRoutes() returned %d routes, want %d", len(router.Routes), test.routes)
+			}
+		})
+	}
+}
+
+func TestHandlerUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		path         string
+		auth         authHeader
+		want         int
+		authenticate bool
+	}{
+		{name: "missing credentials", path: "/", want: http.StatusUnauthorized, authenticate: true},
+		{name: "invalid credentials", path: "/", auth: bearer("wrong"), want: http.StatusUnauthorized, authenticate: true},
+		{name: "invalid room credentials", path: "/%23ops", auth: basic("ops", "x"), want: http.StatusUnauthorized,
+			authenticate: true},
+		{name: "forbidden route", path: "/", auth: basic("ops", "secret"), want: http.StatusForbidden},
+		{name: "forbidden room", path: "/%23web", auth: bearer("db"), want: http.StatusForbidden},
+		{name: "authorized", path: "/", auth: bearer("db"), want: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			testAuthHandler().ServeHTTP(w, newAuthRequest(test.path, test.auth))
+
+			if w.Code != test.want {
+				t.Errorf("ServeHTTP() status = %d, want %d", w.Code, test.want)
+			}
+
+			if got := w.Header().Get("WWW-Authenticate") != ""; got != test.authenticate {
+				t.Errorf("ServeHTTP() WWW-Authenticate header set = %v, want %v", got, test.authenticate)
+			}
+		})
+	}
+}
+
+func TestAuthDisabled(t *testing.T) {
+	t.Parallel()
+
+	h := NewHandler(nil, &HandlerConfig{Router: &Router{Routes: []*Route{{Rooms: []string{"#web"}}}}})
+
+	if _, got := h.authenticateRoutes(newAuthRequest("/", nil)); got != authOK {
+		t.Errorf("authenticateRoutes() = %s without credentials configured, want %s", got, authOK)
+	}
+
+	if got := h.authenticateRoom(newAuthRequest("/", bearer("any")), "#web"); got != authOK {
+		t.Errorf("authenticateRoom() = %s without credentials configured, want %s", got, authOK)
+	}
+}
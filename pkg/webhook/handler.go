@@ -0,0 +1,214 @@
+// Package webhook contains the HTTP handler for messages from the Alertmanager webhook.
+package webhook
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	mid "maunium.net/go/mautrix/id"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/bot"
+)
+
+// HandlerConfig contains the configuration of the webhook handler.
+type HandlerConfig struct {
+	Router     *Router // Router for messages posted without a room (optional).
+	Auth       *Auth   // Credentials for authenticating requests (optional).
+	ShowLabels bool    // Show the labels of alerts in messages.
+}
+
+// Handler handles messages from the Alertmanager webhook.
+// Messages posted to `/{room}` are sent to the given room ID or (URL-encoded) alias,
+// messages posted to `/` are routed to rooms using the Router.
+type Handler struct {
+	Client     *bot.Client
+	Router     *Router
+	Auth       *Auth
+	ShowLabels bool
+
+	mux          *mux.Router
+	authRequired bool
+}
+
+// NewHandler creates a new webhook handler.
+func NewHandler(client *bot.Client, config *HandlerConfig) *Handler {
+	if config == nil {
+		config = new(HandlerConfig)
+	}
+
+	h := &Handler{
+		Client:     client,
+		Router:     cmp.Or(config.Router, new(Router)),
+		Auth:       cmp.Or(config.Auth, new(Auth)),
+		ShowLabels: config.ShowLabels,
+		mux:        mux.NewRouter(),
+	}
+
+	h.authRequired = h.Auth.enabled(h.Router)
+
+	h.mux.HandleFunc("/", h.routeHandler).Methods(http.MethodPost)
+	h.mux.HandleFunc("/{room}", h.roomHandler).Methods(http.MethodPost)
+
+	return h
+}
+
+// ServeHTTP implements [http.Handler].
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// roomHandler sends the alerts to the room ID or alias in the request path.
+func (h *Handler) roomHandler(w http.ResponseWriter, r *http.Request) {
+	// Get room from request
+	room := mux.Vars(r)["room"]
+
+	if res := h.authenticateRoom(r, room); res != authOK {
+		h.unauthorized(w, r, res)
+
+		return
+	}
+
+	roomID, err := h.Client.ResolveRoom(r.Context(), room)
+	if err != nil {
+		log.Printf("Cannot resolve room: %s", err)
+		http.Error(w, err.Error(), resolveErrorStatus(err))
+
+		return
+	}
+
+	data, ok := decodeMessage(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.send(r.Context(), roomID, data, data.Alerts); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// routeHandler sends the alerts to the rooms determined by the router.
+func (h *Handler) routeHandler(w http.ResponseWriter, r *http.Request) {
+	router, res := h.authenticateRoutes(r)
+	if res != authOK {
+		h.unauthorized(w, r, res)
+
+		return
+	}
+
+	data, ok := decodeMessage(w, r)
+	if !ok {
+		return
+	}
+
+	rooms, routed := router.Route(data.Alerts)
+	if len(rooms) == 0 {
+		log.Printf("No route for %d alerts", len(data.Alerts))
+
+		return
+	}
+
+	var errs []error
+
+	for _, room := range rooms {
+		roomID, err := h.Client.ResolveRoom(r.Context(), room)
+		if err != nil {
+			log.Printf("Cannot resolve room in route: %s", err)
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		ctx := bot.WithAlertmanager(r.Context(), router.Alertmanager(room, routed[room]))
+
+		errs = append(errs, h.send(ctx, roomID, data, routed[room]))
+	}
+
+	if errors.Join(errs...) != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// authenticateRoom authenticates a request for a room.
+func (h *Handler) authenticateRoom(r *http.Request, room string) authResult {
+	if !h.authRequired || h.Auth.global(r) || anyMatch(r, h.Auth.Rooms[room]) {
+		return authOK
+	}
+
+	return h.Auth.failure(r, h.Router)
+}
+
+// authenticateRoutes authenticates a request for routing,
+// and returns a router containing the routes that the request is authorized for.
+func (h *Handler) authenticateRoutes(r *http.Request) (*Router, authResult) {
+	if !h.authRequired || h.Auth.global(r) {
+		return h.Router, authOK
+	}
+
+	router := h.Router.restrict(func(route *Route) bool {
+		return anyMatch(r, route.Credentials)
+	})
+
+	if len(router.Routes) == 0 {
+		return nil, h.Auth.failure(r, h.Router)
+	}
+
+	return router, authOK
+}
+
+// unauthorized logs and responds to an unauthorized request.
+// The credentials in the request are not logged.
+func (h *Handler) unauthorized(w http.ResponseWriter, r *http.Request, res authResult) {
+	log.Printf("Unauthorized request from %s for %q: %s", r.RemoteAddr, r.URL.Path, res)
+
+	if res.status() == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="alertmanager_matrix"`)
+	}
+
+	http.Error(w, http.StatusText(res.status()), res.status())
+}
+
+// send sends a notification for the alerts of a message to a room.
+func (h *Handler) send(ctx context.Context, roomID mid.RoomID, msg *alertmanager.Message, alerts []*alertmanager.Alert) error {
+	log.Printf("Sending %d alerts to %s", len(alerts), roomID)
+
+	if err := h.Client.Notify(ctx, roomID, msg, alerts, h.ShowLabels); err != nil {
+		log.Printf("Error sending message: %s", err)
+
+		return err //nolint:wrapcheck // logged and discarded
+	}
+
+	return nil
+}
+
+// resolveErrorStatus returns the HTTP status code for an error returned by [bot.Client.ResolveRoom].
+func resolveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, bot.ErrUnknownAlias):
+		return http.StatusNotFound
+	case errors.Is(err, bot.ErrInvalidRoom):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// decodeMessage parses the message in the request.
+// A bad request response is written on error.
+func decodeMessage(w http.ResponseWriter, r *http.Request) (*alertmanager.Message, bool) {
+	data := new(alertmanager.Message)
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		log.Printf("Error parsing message: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return nil, false
+	}
+
+	return data, true
+}
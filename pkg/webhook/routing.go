@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"slices"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Route represents a route of alerts to one or more rooms.
+type Route struct {
+	// Matchers contains the matchers that an alert must match for this route.
+	// All alerts match if no matchers are given.
+	Matchers labels.Matchers
+
+	// Rooms contains the room IDs or aliases alerts matching this route are sent to.
+	Rooms []string
+
+	// Continue indicates that matching continues with the next route,
+	// even if the alert matches this route.
+	Continue bool
+
+	// Alertmanager is the name of the Alertmanager backend that alerts matching this route are tagged with.
+	// Silences for these alerts are created in this backend. The default backend of the room is used when empty.
+	Alertmanager string
+
+	// Credentials contains webhook credentials that are only valid for this route.
+	// Requests authenticated with these credentials are only routed using the routes they are valid for.
+	Credentials []Credentials
+}
+
+// Match returns true if the given alert matches the route.
+func (r *Route) Match(alert *alertmanager.Alert) bool {
+	return alert.Matches(r.Matchers)
+}
+
+// Router routes alerts to rooms using a list of routes.
+// Routes are evaluated in order, similar to the Alertmanager route tree:
+// the first matching route is used, unless it is configured to continue.
+type Router struct {
+	// Routes contains the routes that are evaluated in order.
+	Routes []*Route
+
+	// Fallback contains the room IDs or aliases alerts are sent to when they match no route.
+	Fallback []string
+}
+
+// restrict returns a router containing only the routes for which the given function returns true.
+// The returned router has no fallback rooms.
+func (r *Router) restrict(allowed func(*Route) bool) *Router {
+	restricted := new(Router)
+
+	for _, route := range r.Routes {
+		if allowed(route) {
+			restricted.Routes = append(restricted.Routes, route)
+		}
+	}
+
+	return restricted
+}
+
+// Rooms returns the rooms that an alert is routed to.
+func (r *Router) Rooms(alert *alertmanager.Alert) (rooms []string) {
+	for _, route := range r.matches(alert) {
+		rooms = appendUnique(rooms, route.Rooms...)
+	}
+
+	if len(rooms) == 0 {
+		return r.Fallback
+	}
+
+	return rooms
+}
+
+// matches returns the routes that an alert matches, stopping at the first route that does not continue.
+func (r *Router) matches(alert *alertmanager.Alert) (routes []*Route) {
+	for _, route := range r.Routes {
+		if !route.Match(alert) {
+			continue
+		}
+
+		routes = append(routes, route)
+
+		if !route.Continue {
+			break
+		}
+	}
+
+	return routes
+}
+
+// Route groups alerts by the rooms they are routed to.
+// The rooms are returned in the order they are first routed to.
+func (r *Router) Route(alerts []*alertmanager.Alert) (rooms []string, routed map[string][]*alertmanager.Alert) {
+	routed = make(map[string][]*alertmanager.Alert)
+
+	for _, alert := range alerts {
+		for _, room := range r.Rooms(alert) {
+			if _, ok := routed[room]; !ok {
+				rooms = append(rooms, room)
+			}
+
+			routed[room] = append(routed[room], alert)
+		}
+	}
+
+	return rooms, routed
+}
+
+// Alertmanager returns the name of the Alertmanager backend for alerts routed to a room.
+// This is the backend of the first matching route to the room that has one,
+// or an empty string if there is none.
+func (r *Router) Alertmanager(room string, alerts []*alertmanager.Alert) string {
+	for _, alert := range alerts {
+		for _, route := range r.matches(alert) {
+			if route.Alertmanager != "" && slices.Contains(route.Rooms, room) {
+				return route.Alertmanager
+			}
+		}
+	}
+
+	return ""
+}
+
+func appendUnique[T comparable](list []T, values ...T) []T {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
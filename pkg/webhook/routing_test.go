@@ -0,0 +1,142 @@
+package webhook_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/alertmanager"
+	"gitlab.com/slxh/matrix/alertmanager_matrix/pkg/webhook"
+)
+
+func newAlert(kv ...string) *alertmanager.Alert {
+	alert := &alertmanager.Alert{Alert: &template.Alert{Labels: template.KV{}}}
+
+	for i := 0; i+1 < len(kv); i += 2 {
+		alert.Labels[kv[i]] = kv[i+1]
+	}
+
+	return alert
+}
+
+func matchers(t *testing.T, s string) labels.Matchers {
+	t.Helper()
+
+	ms, err := labels.ParseMatchers(s)
+	if err != nil {
+		t.Fatalf("ParseMatchers(%q) error = %v", s, err)
+	}
+
+	return ms
+}
+
+func testRouter(t *testing.T) *webhook.Router {
+	t.Helper()
+
+	return &webhook.Router{
+		Routes: []*webhook.Route{
+			{Matchers: matchers(t, `{severity="critical"}`), Rooms: []string{"#oncall"}, Continue: true},
+			{Matchers: matchers(t, `{team="db"}`), Rooms: []string{"#db", "#oncall"}, Alertmanager: "db"},
+			{Matchers: matchers(t, `{team=~"web|api"}`), Rooms: []string{"#web"}},
+			{Matchers: matchers(t, `{team="db"}`), Rooms: []string{"#unreachable"}},
+		},
+		Fallback: []string{"#alerts"},
+	}
+}
+
+func TestRouterRooms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		alert *alertmanager.Alert
+		want  []string
+	}{
+		{name: "first match", alert: newAlert("team", "db"), want: []string{"#db", "#oncall"}},
+		{name: "regexp match", alert: newAlert("team", "api"), want: []string{"#web"}},
+		{name: "continue", alert: newAlert("severity", "critical", "team", "web"), want: []string{"#oncall", "#web"}},
+		{name: "continue without duplicates", alert: newAlert("severity", "critical", "team", "db"),
+			want: []string{"#oncall", "#db"}},
+		{name: "continue to no match", alert: newAlert("severity", "critical"), want: []string{"#oncall"}},
+		{name: "fallback", alert: newAlert("team", "ops"), want: []string{"#alerts"}},
+		{name: "fallback without labels", alert: newAlert(), want: []string{"#alerts"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testRouter(t).Rooms(test.alert); !slices.Equal(got, test.want) {
+				t.Errorf("Rooms() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRouterRoomsWithoutFallback(t *testing.T) {
+	t.Parallel()
+
+	router := testRouter(t)
+	router.Fallback = nil
+
+	if got := router.Rooms(newAlert("team", "ops")); len(got) != 0 {
+		t.Errorf("Rooms() = %v, want none", got)
+	}
+}
+
+func TestRouterRoute(t *testing.T) {
+	t.Parallel()
+
+	db, web, ops := newAlert("team", "db"), newAlert("team", "web"), newAlert("team", "ops")
+
+	rooms, routed := testRouter(t).Route([]*alertmanager.Alert{web, db, ops, newAlert("team", "api")})
+
+	if want := []string{"#web", "#db", "#oncall", "#alerts"}; !slices.Equal(rooms, want) {
+		t.Errorf("Route() rooms = %v, want %v", rooms, want)
+	}
+
+	want := map[string]int{"#web": 2, "#db": 1, "#oncall": 1, "#alerts": 1}
+	got := make(map[string]int)
+
+	for room, alerts := range routed {
+		got[room] = len(alerts)
+	}
+
+	if !maps.Equal(got, want) {
+		t.Errorf("Route() alerts per room = %v, want %v", got, want)
+	}
+}
+
+func TestRouterAlertmanager(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		room   string
+		alerts []*alertmanager.Alert
+		want   string
+	}{
+		{name: "route with backend", room: "#db", alerts: []*alertmanager.Alert{newAlert("team", "db")}, want: "db"},
+		{
+			name:   "continued route without backend",
+			room:   "#oncall",
+			alerts: []*alertmanager.Alert{newAlert("severity", "critical", "team", "db")},
+			want:   "db",
+		},
+		{name: "route without backend", room: "#web", alerts: []*alertmanager.Alert{newAlert("team", "web")}},
+		{name: "fallback", room: "#alerts", alerts: []*alertmanager.Alert{newAlert("team", "ops")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testRouter(t).Alertmanager(test.room, test.alerts); got != test.want {
+				t.Errorf("Alertmanager() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
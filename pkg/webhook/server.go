@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+// ServerConfig contains the configuration of the webhook server.
+type ServerConfig struct {
+	// Address is the address to listen on.
+	Address string
+
+	// TLS contains the TLS configuration in the format used by the Prometheus exporter toolkit.
+	// TLS is disabled when nil.
+	TLS *web.TLSConfig
+
+	// WebConfigFile is the path to a Prometheus exporter toolkit web configuration file.
+	// This enables TLS and basic authentication as configured in the file, and cannot be combined with TLS.
+	// The file is read for every new connection.
+	WebConfigFile string
+}
+
+var errTLSAndWebConfig = errors.New("TLS configuration and web configuration file cannot be combined")
+
+// Server serves a webhook handler using HTTP or HTTPS.
+type Server struct {
+	*http.Server
+
+	config    *ServerConfig
+	tlsConfig atomic.Pointer[tls.Config]
+}
+
+// NewServer creates a new server for the given handler.
+// The TLS configuration is loaded and validated if TLS is configured.
+func NewServer(handler http.Handler, config *ServerConfig) (*Server, error) {
+	if config.TLS != nil && config.WebConfigFile != "" {
+		return nil, errTLSAndWebConfig
+	}
+
+	s := &Server{
+		Server: &http.Server{Addr: config.Address, Handler: handler, ReadTimeout: time.Second},
+		config: config,
+	}
+
+	if err := web.Validate(config.WebConfigFile); err != nil {
+		return nil, fmt.Errorf("invalid web configuration file: %w", err)
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload reloads the TLS certificates and client CA without interrupting the server.
+// It is a no-op if TLS is not configured.
+// The certificates are kept if an error is returned.
+func (s *Server) Reload() error {
+	if s.config.TLS == nil {
+		return nil
+	}
+
+	config, err := web.ConfigToTLSConfig(s.config.TLS)
+	if err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	s.tlsConfig.Store(config)
+
+	return nil
+}
+
+// ListenAndServe listens on the configured address and serves the handler.
+func (s *Server) ListenAndServe() error {
+	switch {
+	case s.config.WebConfigFile != "":
+		return web.ListenAndServe(s.Server, &web.FlagConfig{ //nolint:wrapcheck // transparent wrapper
+			WebListenAddresses: &[]string{s.config.Address},
+			WebSystemdSocket:   new(bool),
+			WebConfigFile:      &s.config.WebConfigFile,
+		}, slog.Default())
+	case s.config.TLS != nil:
+		s.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+				return s.tlsConfig.Load(), nil
+			},
+		}
+
+		log.Print("Listening with TLS on ", s.Addr)
+
+		return s.Server.ListenAndServeTLS("", "") //nolint:wrapcheck // transparent wrapper
+	default:
+		log.Print("Listening on ", s.Addr)
+
+		return s.Server.ListenAndServe() //nolint:wrapcheck // transparent wrapper
+	}
+}